@@ -0,0 +1,154 @@
+package prettier
+
+import (
+	"fmt"
+	"math/rand"
+	"strconv"
+	"testing"
+)
+
+var benchmarkSizes = []int{100, 1000, 10000}
+
+var benchmarkWidths = []int{40, 80, 120}
+
+// words builds n texts of random lengths from 1 to 10.
+func words(n int) []Doc {
+	r := rand.New(rand.NewSource(1))
+	ds := make([]Doc, n)
+	for i := range ds {
+		ds[i] = Text("abcdefghij"[:1+r.Intn(10)])
+	}
+	return ds
+}
+
+// nestedGroups builds `[ [ ... [ x ] ... ] ]` nested depth times,
+// each level being a group. The groups are not indented so that
+// the size of the output is linear in depth.
+func nestedGroups(depth int) Doc {
+	doc := Text("x")
+	for i := 0; i < depth; i++ {
+		doc = BracketBy(Text("["), Text("]"), doc, uint(0))
+	}
+	return doc
+}
+
+// jsonLike builds a JSON-like document of objects and arrays with about
+// n values, nested at most depth times.
+func jsonLike(r *rand.Rand, n int, depth int) Doc {
+	if n <= 1 || depth == 0 {
+		return Text(strconv.Itoa(r.Intn(100000)))
+	}
+	size := 2 + r.Intn(8)
+	items := make([]Doc, size)
+	object := r.Intn(2) == 0
+	for i := range items {
+		item := jsonLike(r, n/size, depth-1)
+		if object {
+			item = Concat([]Doc{Text(strconv.Quote(fmt.Sprintf("key%d", i))), Text(": "), item})
+		}
+		items[i] = item
+	}
+	body := Intercalate(Concat([]Doc{Text(","), Line()}), items)
+	if object {
+		return BracketBy(Text("{"), Text("}"), body, uint(2))
+	}
+	return TightBracketBy(Text("["), Text("]"), body, uint(2))
+}
+
+func BenchmarkConcat(b *testing.B) {
+	for _, n := range benchmarkSizes {
+		ds := words(n)
+		b.Run(fmt.Sprintf("build/n=%d", n), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				Intercalate(Line(), ds)
+			}
+		})
+		b.Run(fmt.Sprintf("render/n=%d", n), func(b *testing.B) {
+			doc := Group(Intercalate(Line(), ds))
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				Pretty(80, doc)
+			}
+		})
+	}
+}
+
+func BenchmarkGroupNested(b *testing.B) {
+	for _, depth := range benchmarkSizes {
+		b.Run(fmt.Sprintf("build/depth=%d", depth), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				nestedGroups(depth)
+			}
+		})
+		b.Run(fmt.Sprintf("render/depth=%d", depth), func(b *testing.B) {
+			doc := nestedGroups(depth)
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				Pretty(80, doc)
+			}
+		})
+	}
+}
+
+func BenchmarkFill(b *testing.B) {
+	for _, n := range benchmarkSizes {
+		ds := words(n)
+		b.Run(fmt.Sprintf("build/n=%d", n), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				Fill(Line(), ds)
+			}
+		})
+		b.Run(fmt.Sprintf("render/n=%d", n), func(b *testing.B) {
+			doc := Fill(Line(), ds)
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				Pretty(80, doc)
+			}
+		})
+	}
+}
+
+func BenchmarkBracketBy(b *testing.B) {
+	for _, n := range benchmarkSizes {
+		b.Run(fmt.Sprintf("build/n=%d", n), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				jsonLike(rand.New(rand.NewSource(1)), n, 8)
+			}
+		})
+		b.Run(fmt.Sprintf("render/n=%d", n), func(b *testing.B) {
+			doc := jsonLike(rand.New(rand.NewSource(1)), n, 8)
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				Pretty(80, doc)
+			}
+		})
+	}
+}
+
+func BenchmarkWidths(b *testing.B) {
+	doc := jsonLike(rand.New(rand.NewSource(1)), 10000, 8)
+	for _, width := range benchmarkWidths {
+		b.Run(fmt.Sprintf("width=%d", width), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				Pretty(width, doc)
+			}
+		})
+	}
+	b.Run("all", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			for _, width := range benchmarkWidths {
+				Pretty(width, doc)
+			}
+		}
+	})
+}
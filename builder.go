@@ -66,6 +66,32 @@ func Nest(indent uint, doc Doc) Doc {
 	}
 }
 
+// Align sets the nesting of the document at the current column, so that
+// newlines in this document will be followed by indentation up to the
+// column where the document starts.
+func Align(doc Doc) Doc {
+	return &align{
+		doc: doc,
+	}
+}
+
+// Annotate attaches the given value to a document.
+// Annotations don't affect the layout, but the range of the output
+// that the annotated document is rendered to can be recovered with Render.
+func Annotate(value interface{}, doc Doc) Doc {
+	return &annotation{
+		value: value,
+		doc:   doc,
+	}
+}
+
+// Cursor represents a zero-width marker, which is typically placed
+// where the cursor of an editor is. Render reports the positions where
+// the markers end up in the output, so put two markers to track a selection.
+func Cursor() Doc {
+	return &cursor{}
+}
+
 // BracketBy bookend specified Doc between the given Docs.
 //
 // If the documents (when flattened) all fit on one line, then
@@ -116,57 +142,49 @@ func Spaces(n uint) Doc {
 // Group treats the specified doc as a group that can be compressed.
 // The effect of this is to replace newlines with spaces, if there
 // is enough room. Otherwise, the Doc will be rendered as-it is.
+//
+// The flattened layout is not built here, but the renderer lays out
+// the doc flat, so that building nested groups takes linear time.
 func Group(doc Doc) Doc {
-	flattened, changed := doc.flattenBool()
-	if changed {
-		return &union{a: flattened, b: doc}
+	switch doc.(type) {
+	case *empty, *text, *cursor, *group:
+		// there is nothing to flatten, or it's already grouped.
+		return doc
+	}
+	return &group{doc: doc}
+}
+
+// IfBreak represents a document which is laid out as `broken` if the
+// enclosing group is broken into lines, and as `flat` if the group is
+// laid out flat. For example, `IfBreak(Text(","), Empty())` is a trailing
+// comma which is only written when the elements are laid out on
+// separate lines.
+func IfBreak(broken Doc, flat Doc) Doc {
+	return &ifBreak{
+		broken: broken,
+		flat:   flat,
 	}
-	return flattened
 }
 
 // Fill collapse a collection of documents into one document, delimited
 // by a specified separator.
+//
+// Each part is laid out flat followed by the flattened separator if they
+// fit on the current line together with the next part laid out flat.
+// Otherwise the part is laid out as usual and the separator is not
+// flattened, which usually starts a new line. The separator after a part
+// which is broken into lines, such as a line, a group or a fill, is thus
+// never flattened, even if the next part fits on the last line of the part.
+// Rendering a Fill takes time linear in the number of parts.
 func Fill(sep Doc, parts []Doc) Doc {
-	flatSep, _ := sep.flattenBool()
-	sepGroup := Group(sep)
 	if len(parts) == 0 {
 		return &empty{}
 	} else if len(parts) == 1 {
 		return parts[0]
-	} else {
-		x := parts[0]
-		y := parts[1]
-		tail := parts[2:]
-		flatx, changedx := x.flattenBool()
-		flaty, changedy := y.flattenBool()
-		if changedx && changedy {
-			filling := append([]Doc{flaty}, tail...)
-			first := Concat([]Doc{flatx, flatSep, Fill(sep, filling)})
-			lazy := lazy(func() Doc {
-				return Fill(sep, parts[1:])
-			})
-			second := Concat([]Doc{x, sep, lazy})
-			return &union{a: first, b: second}
-		} else if !changedx && changedy {
-			filling := append([]Doc{flaty}, tail...)
-			first := Concat([]Doc{flatx, flatSep, Fill(sep, filling)})
-			lazy := lazy(func() Doc {
-				return Fill(sep, parts[1:])
-			})
-			second := Concat([]Doc{x, sep, lazy})
-			return &union{a: first, b: second}
-		} else if changedx && !changedy {
-			// y == flaty
-			filling := append([]Doc{flaty}, tail...)
-			filled := Fill(sep, filling)
-			first := Concat([]Doc{flatx, flatSep, filled})
-			second := Concat([]Doc{x, sep, filled})
-			return &union{a: first, b: second}
-		} else { // !changedx && !changedy
-			// x == flatx
-			// y == flaty
-			return Concat([]Doc{flatx, sepGroup, Fill(sep, parts[1:])})
-		}
+	}
+	return &fill{
+		sep:   sep,
+		parts: append([]Doc{}, parts...),
 	}
 }
 
@@ -203,10 +221,16 @@ func Intercalate(sep Doc, ds []Doc) Doc {
 	return Concat(join)
 }
 
-// lazy creates a Doc which won't be evaluated until needed.
+// Lazy creates a Doc which is built by f when it is needed.
 // This is useful in some recursive algorithms.
-func lazy(f func() Doc) lazyDoc {
-	ch := make(lazyDoc, 1)
-	ch <- f
-	return ch
+//
+// f is called at most once, even if the Doc is rendered many times or
+// from multiple goroutines concurrently. If f panics, every use of
+// the Doc panics with the same value.
+func Lazy(f func() Doc) Doc {
+	return lazy(f)
+}
+
+func lazy(f func() Doc) *lazyDoc {
+	return &lazyDoc{f: f}
 }
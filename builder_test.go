@@ -3,6 +3,7 @@ package prettier
 import (
 	"math/rand"
 	"reflect"
+	"strings"
 	"testing"
 	"testing/quick"
 )
@@ -80,6 +81,25 @@ func TestNest(t *testing.T) {
 	}
 }
 
+func TestAnnotate(t *testing.T) {
+	doc := Annotate("id", Text("test"))
+	expected := &annotation{
+		value: "id",
+		doc:   Text("test"),
+	}
+	if !reflect.DeepEqual(doc, expected) {
+		t.Errorf("expected: %v, actual: %v", expected, doc)
+	}
+}
+
+func TestCursor(t *testing.T) {
+	doc := Cursor()
+	expected := &cursor{}
+	if !reflect.DeepEqual(doc, expected) {
+		t.Errorf("expected: %v, actual: %v", expected, doc)
+	}
+}
+
 func TestBracketBy(t *testing.T) {
 	sep := Concat([]Doc{Text(","), LineOrSpace()})
 	ds := []Doc{
@@ -161,14 +181,17 @@ func TestGroup(t *testing.T) {
 		t.Errorf("Group shouldn't construct union if nothing is flattened")
 	}
 
-	flatline, _ := Line().flattenBool()
 	lineOrSpace := Group(Line())
-	lineOrSpaceExpected := &union{
-		a: flatline,
-		b: Line(),
+	lineOrSpaceExpected := &group{
+		doc: Line(),
 	}
 	if !reflect.DeepEqual(lineOrSpace, lineOrSpaceExpected) {
-		t.Errorf("Group should construct union of flatened doc and doc as it is.")
+		t.Errorf("Group should construct group of doc as it is.")
+	}
+
+	nested := Group(lineOrSpace)
+	if !reflect.DeepEqual(nested, lineOrSpaceExpected) {
+		t.Errorf("Group shouldn't group a group again")
 	}
 }
 
@@ -221,6 +244,106 @@ func TestFill(t *testing.T) {
 	}
 }
 
+func TestAlign(t *testing.T) {
+	args := Align(Intercalate(Line(), []Doc{Text("b"), Text("c")}))
+	doc := Concat([]Doc{Text("(a "), args, Text(")")})
+	if Pretty(80, doc) != "(a b\n   c)" {
+		t.Errorf("expected: %q, actual: %q", "(a b\n   c)", Pretty(80, doc))
+	}
+	if Pretty(80, Group(doc)) != "(a b c)" {
+		t.Errorf("expected: %q, actual: %q", "(a b c)", Pretty(80, Group(doc)))
+	}
+	nested := Nest(uint(2), Concat([]Doc{Line(), Text("x "), Align(Concat([]Doc{Text("y"), Line(), Text("z")}))}))
+	if Pretty(80, nested) != "\n  x y\n    z" {
+		t.Errorf("expected: %q, actual: %q", "\n  x y\n    z", Pretty(80, nested))
+	}
+}
+
+func TestIfBreak(t *testing.T) {
+	sep := Concat([]Doc{Text(","), Line()})
+	body := Concat([]Doc{Intercalate(sep, []Doc{Text("a"), Text("b")}), IfBreak(Text(","), Empty())})
+	doc := TightBracketBy(Text("["), Text("]"), body, uint(2))
+	if Pretty(10, doc) != "[a, b]" {
+		t.Errorf("expected: %q, actual: %q", "[a, b]", Pretty(10, doc))
+	}
+	if Pretty(5, doc) != "[\n  a,\n  b,\n]" {
+		t.Errorf("expected: %q, actual: %q", "[\n  a,\n  b,\n]", Pretty(5, doc))
+	}
+	// IfBreak outside of groups is broken.
+	if Pretty(10, IfBreak(Text("broken"), Text("flat"))) != "broken" {
+		t.Errorf("expected: %q, actual: %q", "broken", Pretty(10, IfBreak(Text("broken"), Text("flat"))))
+	}
+}
+
+func TestFillNode(t *testing.T) {
+	sep := Concat([]Doc{Text(","), Line()})
+	if !reflect.DeepEqual(Fill(sep, []Doc{}), Empty()) {
+		t.Errorf("Fill of no parts should be empty")
+	}
+	if !reflect.DeepEqual(Fill(sep, []Doc{Text("a")}), Text("a")) {
+		t.Errorf("Fill of a part should be the part")
+	}
+	parts := []Doc{Text("a"), Text("b")}
+	doc := Fill(sep, parts)
+	parts[0] = Text("c")
+	if Pretty(10, doc) != "a, b" {
+		t.Errorf("Fill should not share the parts with the caller, actual: %v", Pretty(10, doc))
+	}
+}
+
+func TestFillInGroup(t *testing.T) {
+	sep := Concat([]Doc{Text(","), Line()})
+	doc := BracketBy(Text("["), Text("]"), Fill(sep, []Doc{Text("a"), Text("b"), Text("c")}), uint(2))
+	if Pretty(20, doc) != "[ a, b, c ]" {
+		t.Errorf("expected: %q, actual: %q", "[ a, b, c ]", Pretty(20, doc))
+	}
+	if Pretty(8, doc) != "[\n  a, b,\n  c\n]" {
+		t.Errorf("expected: %q, actual: %q", "[\n  a, b,\n  c\n]", Pretty(8, doc))
+	}
+}
+
+func TestFillBreakableParts(t *testing.T) {
+	sep := Concat([]Doc{Text(","), Line()})
+	group := Group(Concat([]Doc{Text("a"), Line(), Text("b")}))
+	inner := Fill(Text(","), []Doc{Line(), Text("abcd"), Line(), Text("abcd")})
+	tests := []struct {
+		width    int
+		doc      Doc
+		expected string
+	}{
+		{40, BracketBy(Text("["), Text("]"), Fill(sep, []Doc{group, Text("cc"), Text("dd")}), uint(2)), "[ a b, cc, dd ]"},
+		{8, BracketBy(Text("["), Text("]"), Fill(sep, []Doc{group, Text("cc"), Text("dd")}), uint(2)), "[\n  a b,\n  cc, dd\n]"},
+		{4, BracketBy(Text("["), Text("]"), Fill(sep, []Doc{group, Text("cc"), Text("dd")}), uint(2)), "[\n  a\n  b,\n  cc,\n  dd\n]"},
+		{40, BracketBy(Text("["), Text("]"), Fill(sep, []Doc{inner, Text("ab")}), uint(0)), "[  ,abcd, ,abcd, ab ]"},
+		{14, BracketBy(Text("["), Text("]"), Fill(sep, []Doc{inner, Text("ab")}), uint(0)), "[\n ,abcd, ,abcd,\nab\n]"},
+		// the separator after the broken part is not flattened.
+		{10, BracketBy(Text("["), Text("]"), Fill(sep, []Doc{inner, Text("ab")}), uint(0)), "[\n ,abcd,\n,abcd,\nab\n]"},
+		{6, BracketBy(Text("["), Text("]"), Fill(Text(","), []Doc{Line(), Line(), Text("abcd"), Text("abcd"), Text("ab")}), uint(0)), "[\n\n,\n,abcd,abcd,ab\n]"},
+	}
+	for _, tt := range tests {
+		if actual := Pretty(tt.width, tt.doc); actual != tt.expected {
+			t.Errorf("expected: %q, actual: %q", tt.expected, actual)
+		}
+	}
+}
+
+func TestFillLarge(t *testing.T) {
+	words := make([]Doc, 100000)
+	for i := range words {
+		words[i] = Text("word")
+	}
+	lines := strings.Split(Pretty(80, Fill(Line(), words)), "\n")
+	// 16 words of 4 letters and 15 spaces fit in 80 columns.
+	if len(lines) != 6250 {
+		t.Errorf("expected: %v lines, actual: %v lines", 6250, len(lines))
+	}
+	for _, l := range lines {
+		if len(l) != 79 {
+			t.Fatalf("expected: %v columns, actual: %q", 79, l)
+		}
+	}
+}
+
 func TestFoldDocs(t *testing.T) {
 	ds := []Doc{Text("a"), Text("b"), Text("c")}
 	f := func(a Doc, b Doc) Doc {
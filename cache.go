@@ -0,0 +1,96 @@
+package prettier
+
+// cache memoizes the widths of groups and fills laid out flat while
+// rendering a document, so that the renderer can tell that a group
+// doesn't fit within the width without laying it out. The layouts are not
+// memoized: the renderer lays out the first line of a layout lazily to see
+// if it fits, and reuses it when it is chosen.
+type cache struct {
+	// flatWidths maps the groups and the fills to their flat widths.
+	flatWidths map[Doc]int
+}
+
+func newCache() *cache {
+	return &cache{flatWidths: map[Doc]int{}}
+}
+
+// flatWidth returns the width of the given Doc laid out flat, or -1 if it
+// is not known without evaluating lazy documents.
+func (c *cache) flatWidth(doc Doc) int {
+	return c.measure(doc)
+}
+
+func (c *cache) measure(doc Doc) int {
+	width := 0
+	for {
+		switch v := doc.(type) {
+		case *text:
+			return width + v.length
+		case *line:
+			if v.flattenToSpace {
+				return width + 1
+			}
+			return width
+		case *concat:
+			a := c.measure(v.a)
+			if a < 0 {
+				return -1
+			}
+			width += a
+			doc = v.b
+		case *nest:
+			doc = v.doc
+		case *align:
+			doc = v.doc
+		case *annotation:
+			doc = v.doc
+		case *union:
+			doc = v.a
+		case *ifBreak:
+			doc = v.flat
+		case *lazyDoc:
+			if !v.evaluated() {
+				return -1
+			}
+			doc = v.Evaluated()
+		case *group, *fill:
+			// only groups and fills are memoized, since the renderer
+			// asks for their widths.
+			if w, ok := c.flatWidths[doc]; ok {
+				return width + w
+			}
+			w := c.measureNode(doc)
+			if w < 0 {
+				// it may be known once the lazy documents are evaluated.
+				return -1
+			}
+			c.flatWidths[doc] = w
+			return width + w
+		default:
+			return width
+		}
+	}
+}
+
+func (c *cache) measureNode(doc Doc) int {
+	switch v := doc.(type) {
+	case *group:
+		return c.measure(v.doc)
+	case *fill:
+		sep := c.measure(v.sep)
+		if sep < 0 {
+			return -1
+		}
+		width := sep * (len(v.parts) - 1)
+		for _, part := range v.parts {
+			w := c.measure(part)
+			if w < 0 {
+				return -1
+			}
+			width += w
+		}
+		return width
+	default:
+		return c.measure(doc)
+	}
+}
@@ -0,0 +1,52 @@
+package prettier
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestCacheSameLayout(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 300; i++ {
+		doc := generateRandomTree(r, 5)
+		for width := 0; width < 30; width += 3 {
+			p := &printer{width: width}
+			expected := render(p.best(uint(0), doc)).Output
+			if actual := Pretty(width, doc); actual != expected {
+				t.Fatalf("expected: %q, actual: %q", expected, actual)
+			}
+		}
+	}
+}
+
+func TestCacheMemoized(t *testing.T) {
+	inner := Group(Concat([]Doc{Text("a"), Line(), Text("b")}))
+	doc := Group(Concat([]Doc{Text("["), Line(), inner, Line(), Text("]")}))
+	p := &printer{width: 4, cache: newCache()}
+	if actual := render(p.best(uint(0), doc)).Output; actual != "[\na b\n]" {
+		t.Errorf("expected: %q, actual: %q", "[\na b\n]", actual)
+	}
+	if p.cache.flatWidths[doc] != 7 || p.cache.flatWidths[inner] != 3 {
+		t.Errorf("flat widths of groups should be memoized, actual: %v", p.cache.flatWidths)
+	}
+}
+
+func TestCacheLazy(t *testing.T) {
+	evaluated := false
+	l := lazy(func() Doc {
+		evaluated = true
+		return Text("abc")
+	})
+	doc := Group(Concat([]Doc{Text("a"), Line(), l}))
+	c := newCache()
+	if c.flatWidth(doc) != -1 || evaluated {
+		t.Errorf("the width of unevaluated lazy documents should be unknown")
+	}
+	if _, ok := c.flatWidths[doc]; ok {
+		t.Errorf("unknown widths should not be memoized")
+	}
+	l.Evaluated()
+	if c.flatWidth(doc) != 5 {
+		t.Errorf("expected: %v, actual: %v", 5, c.flatWidth(doc))
+	}
+}
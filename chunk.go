@@ -62,3 +62,108 @@ func (l *lineChunk) fits(width int) bool {
 func (l *lineChunk) String() string {
 	return fmt.Sprintf("LineChunk(%v, %v)", l.indent, l.c.String())
 }
+
+type annotationChunk struct {
+	value interface{}
+	start bool
+	c     chunk
+}
+
+func (a *annotationChunk) layout() string {
+	return a.c.layout()
+}
+
+func (a *annotationChunk) fits(width int) bool {
+	return a.c.fits(width)
+}
+
+func (a *annotationChunk) String() string {
+	if a.start {
+		return fmt.Sprintf("AnnotationStartChunk(%v, %v)", a.value, a.c.String())
+	}
+	return fmt.Sprintf("AnnotationEndChunk(%v, %v)", a.value, a.c.String())
+}
+
+type cursorChunk struct {
+	c chunk
+}
+
+func (c *cursorChunk) layout() string {
+	return c.c.layout()
+}
+
+func (c *cursorChunk) fits(width int) bool {
+	return c.c.fits(width)
+}
+
+func (c *cursorChunk) String() string {
+	return fmt.Sprintf("CursorChunk(%v)", c.c.String())
+}
+
+type traceChunk struct {
+	decision Decision
+	c        chunk
+}
+
+func (t *traceChunk) layout() string {
+	return t.c.layout()
+}
+
+func (t *traceChunk) fits(width int) bool {
+	return t.c.fits(width)
+}
+
+func (t *traceChunk) String() string {
+	return fmt.Sprintf("TraceChunk(%v, %v)", t.decision, t.c.String())
+}
+
+// overflowText returns the text in the first line of the layout
+// which makes the line longer than width.
+func overflowText(c chunk, width int) string {
+	for {
+		switch v := c.(type) {
+		case *textChunk:
+			width -= v.strLength
+			if width < 0 {
+				return v.str
+			}
+			c = v.c
+		case *annotationChunk:
+			c = v.c
+		case *cursorChunk:
+			c = v.c
+		case *traceChunk:
+			c = v.c
+		case *lazyChunk:
+			c = v.force()
+		default:
+			return ""
+		}
+	}
+}
+
+// lazyChunk is a chunk which is laid out when it is needed.
+type lazyChunk struct {
+	f func() chunk
+	c chunk
+}
+
+func (l *lazyChunk) force() chunk {
+	if l.f != nil {
+		l.c = l.f()
+		l.f = nil
+	}
+	return l.c
+}
+
+func (l *lazyChunk) layout() string {
+	return l.force().layout()
+}
+
+func (l *lazyChunk) fits(width int) bool {
+	return l.force().fits(width)
+}
+
+func (l *lazyChunk) String() string {
+	return l.force().String()
+}
@@ -0,0 +1,164 @@
+package prettier
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// DebugOptions configures the output of DebugWith.
+type DebugOptions struct {
+	// Width is the width of the dump. Zero means 80.
+	Width int
+	// GoSyntax dumps the Go expression which builds the document with
+	// this package, imported as "prettier". Lazy documents are shown as
+	// the evaluated documents, and subdocuments shared in the Doc are
+	// repeated.
+	GoSyntax bool
+}
+
+// Debug dumps the tree of the given Doc in a human-readable way.
+//
+// Subdocuments which appear more than
+// once in the tree are labelled as `#n=Doc(...)` at the first appearance,
+// and are referred to as `#n` after that.
+// Lazy documents are evaluated to be shown.
+func Debug(doc Doc) string {
+	return DebugWith(doc, DebugOptions{})
+}
+
+// DebugWith dumps the tree of the given Doc in the same way as Debug,
+// with the given options.
+func DebugWith(doc Doc, opts DebugOptions) string {
+	width := opts.Width
+	if width == 0 {
+		width = 80
+	}
+	d := &debugger{
+		goSyntax: opts.GoSyntax,
+		count:    map[Doc]int{},
+		labels:   map[Doc]int{},
+	}
+	d.countShared(doc)
+	return Pretty(width, d.dump(doc))
+}
+
+type debugger struct {
+	goSyntax bool
+	count    map[Doc]int
+	labels   map[Doc]int
+}
+
+func (d *debugger) countShared(doc Doc) {
+	switch doc.(type) {
+	case *empty, *text, *line, *cursor:
+		// leaves are cheap to repeat.
+		return
+	}
+	d.count[doc]++
+	if d.count[doc] > 1 {
+		return
+	}
+	for _, child := range Children(doc) {
+		d.countShared(child)
+	}
+}
+
+func (d *debugger) dump(doc Doc) Doc {
+	if d.goSyntax || d.count[doc] <= 1 {
+		return d.dumpNode(doc)
+	}
+	if label, ok := d.labels[doc]; ok {
+		return Text(fmt.Sprintf("#%d", label))
+	}
+	label := len(d.labels) + 1
+	d.labels[doc] = label
+	return Concat([]Doc{Text(fmt.Sprintf("#%d=", label)), d.dumpNode(doc)})
+}
+
+func (d *debugger) dumpNode(doc Doc) Doc {
+	switch v := doc.(type) {
+	case *empty:
+		return d.call("Empty")
+	case *text:
+		if v.length == len([]rune(v.str)) {
+			return d.call("Text", Text(strconv.Quote(v.str)))
+		}
+		return d.call("TextWithLength", Text(strconv.Quote(v.str)), Text(strconv.Itoa(v.length)))
+	case *line:
+		if v.flattenToSpace {
+			return d.call("Line")
+		}
+		return d.call("LineBreak")
+	case *concat:
+		parts := []Doc{d.dump(v.a)}
+		var rest Doc = v.b
+		for {
+			// flatten right-associated concatenations which are not shared.
+			c, ok := rest.(*concat)
+			if !ok || (!d.goSyntax && d.count[c] > 1) {
+				break
+			}
+			parts = append(parts, d.dump(c.a))
+			rest = c.b
+		}
+		parts = append(parts, d.dump(rest))
+		if d.goSyntax {
+			return d.bracket(Text("prettier.Concat([]prettier.Doc{"), Text("})"), parts)
+		}
+		return d.call("Concat", parts...)
+	case *nest:
+		return d.call("Nest", Text(strconv.FormatUint(uint64(v.indent), 10)), d.dump(v.doc))
+	case *group:
+		return d.call("Group", d.dump(v.doc))
+	case *align:
+		return d.call("Align", d.dump(v.doc))
+	case *ifBreak:
+		return d.call("IfBreak", d.dump(v.broken), d.dump(v.flat))
+	case *fill:
+		parts := make([]Doc, len(v.parts))
+		for i, part := range v.parts {
+			parts[i] = d.dump(part)
+		}
+		if d.goSyntax {
+			return d.call("Fill", d.dump(v.sep), d.bracket(Text("[]prettier.Doc{"), Text("}"), parts))
+		}
+		return d.call("Fill", d.dump(v.sep), d.bracket(Text("["), Text("]"), parts))
+	case *union:
+		if d.goSyntax {
+			// there is no builder for unions.
+			return Concat([]Doc{Text("/* union */ "), d.dump(v.b)})
+		}
+		return d.call("Union", d.dump(v.a), d.dump(v.b))
+	case *lazyDoc:
+		if d.goSyntax {
+			return d.dump(v.Evaluated())
+		}
+		return d.call("Lazy", d.dump(v.Evaluated()))
+	case *annotation:
+		return d.call("Annotate", Text(fmt.Sprintf("%#v", v.value)), d.dump(v.doc))
+	case *cursor:
+		return d.call("Cursor")
+	default:
+		return Text(doc.String())
+	}
+}
+
+func (d *debugger) call(name string, args ...Doc) Doc {
+	if d.goSyntax {
+		name = "prettier." + name
+	}
+	return d.bracket(Text(name+"("), Text(")"), args)
+}
+
+func (d *debugger) bracket(left Doc, right Doc, args []Doc) Doc {
+	if len(args) == 0 {
+		return Concat([]Doc{left, right})
+	}
+	body := Intercalate(Concat([]Doc{Text(","), Line()}), args)
+	if d.goSyntax {
+		// Go requires a trailing comma if the closing bracket is on its
+		// own line, so close it right after the last argument.
+		return Group(Concat([]Doc{left, Nest(uint(2), Concat([]Doc{LineBreak(), body})), right}))
+	}
+	return TightBracketBy(left, right, body, uint(2))
+}
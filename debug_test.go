@@ -0,0 +1,76 @@
+package prettier
+
+import (
+	"testing"
+)
+
+func TestDebug(t *testing.T) {
+	sep := Concat([]Doc{Text(","), Line()})
+	doc := TightBracketBy(Text("["), Text("]"), Concat([]Doc{Text("foo"), sep, Annotate("id", TextWithLength("bar", 1)), sep}), uint(2))
+	expected := `Concat(
+  Text("["),
+  Group(
+    Concat(
+      Nest(
+        2,
+        Concat(
+          LineBreak(),
+          Text("foo"),
+          #1=Concat(Text(","), Line()),
+          Annotate("id", TextWithLength("bar", 1)),
+          #1
+        )
+      ),
+      LineBreak(),
+      Text("]")
+    )
+  )
+)`
+	if Debug(doc) != expected {
+		t.Errorf("expected: %v, actual: %v", expected, Debug(doc))
+	}
+}
+
+func TestDebugUnionAndLazy(t *testing.T) {
+	doc := &union{
+		a: Text("a"),
+		b: lazy(func() Doc { return Concat([]Doc{Cursor(), Empty()}) }),
+	}
+	expected := `Union(Text("a"), Lazy(Concat(Cursor(), Empty())))`
+	if Debug(doc) != expected {
+		t.Errorf("expected: %v, actual: %v", expected, Debug(doc))
+	}
+}
+
+func TestDebugFill(t *testing.T) {
+	doc := Fill(Line(), []Doc{Text("a"), Text("b")})
+	expected := `Fill(Line(), [Text("a"), Text("b")])`
+	if Debug(doc) != expected {
+		t.Errorf("expected: %v, actual: %v", expected, Debug(doc))
+	}
+	expected = `prettier.Fill(prettier.Line(), []prettier.Doc{prettier.Text("a"), prettier.Text("b")})`
+	actual := DebugWith(doc, DebugOptions{GoSyntax: true, Width: 100})
+	if actual != expected {
+		t.Errorf("expected: %v, actual: %v", expected, actual)
+	}
+}
+
+func TestDebugGoSyntax(t *testing.T) {
+	sep := Concat([]Doc{Text(","), Line()})
+	doc := Group(Concat([]Doc{Text("foo"), sep, Nest(uint(2), Text("bar")), sep}))
+	expected := `prettier.Group(
+  prettier.Concat([]prettier.Doc{
+    prettier.Text("foo"),
+    prettier.Concat([]prettier.Doc{prettier.Text(","), prettier.Line()}),
+    prettier.Nest(2, prettier.Text("bar")),
+    prettier.Text(","),
+    prettier.Line()}))`
+	actual := DebugWith(doc, DebugOptions{GoSyntax: true})
+	if actual != expected {
+		t.Errorf("expected: %v, actual: %v", expected, actual)
+	}
+	narrow := DebugWith(Text("foo"), DebugOptions{GoSyntax: true, Width: 10})
+	if narrow != "prettier.Text(\n  \"foo\")" {
+		t.Errorf("expected: %q, actual: %q", "prettier.Text(\n  \"foo\")", narrow)
+	}
+}
@@ -2,6 +2,9 @@ package prettier
 
 import (
 	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
 )
 
 // Doc represents set of layouts.
@@ -51,23 +54,21 @@ func (l *line) flattenBool() (Doc, bool) {
 	return flattened, true
 }
 
-// Align sets the nesting at the current position
-// type align struct {
-// 	doc Doc
-// }
-//
-// func (a *align) String() string {
-// 	return fmt.Sprintf("Align(%v)", a.doc.String())
-// }
-//
-// func (a *align) flattenBool() Doc {
-// 	flattened, changed := a.doc.flattenBool()
-// 	return (
-// 		&align{
-//
-// 		}
-// 	)
-// }
+// align sets the nesting at the current position.
+type align struct {
+	doc Doc
+}
+
+func (a *align) String() string {
+	return fmt.Sprintf("Align(%v)", a.doc.String())
+}
+
+func (a *align) flattenBool() (Doc, bool) {
+	flattened, changed := a.doc.flattenBool()
+	return &align{
+		doc: flattened,
+	}, changed
+}
 
 type concat struct {
 	a Doc
@@ -115,21 +116,122 @@ func (u *union) flattenBool() (Doc, bool) {
 	return u.a, true
 }
 
-type lazyDoc chan func() Doc
+type group struct {
+	doc Doc
+}
+
+func (g *group) String() string {
+	return fmt.Sprintf("Group(%v)", g.doc.String())
+}
+
+func (g *group) flattenBool() (Doc, bool) {
+	return g.doc.flattenBool()
+}
+
+// ifBreak is laid out as broken unless it is laid out flat
+// in a group.
+type ifBreak struct {
+	broken Doc
+	flat   Doc
+}
+
+func (i *ifBreak) String() string {
+	return fmt.Sprintf("IfBreak(%v, %v)", i.broken.String(), i.flat.String())
+}
 
-func (l lazyDoc) String() string {
+func (i *ifBreak) flattenBool() (Doc, bool) {
+	flattened, _ := i.flat.flattenBool()
+	return flattened, true
+}
+
+// fill lays out as many parts as possible on each line, separated by sep
+// which is flattened between the parts on the same line.
+type fill struct {
+	sep   Doc
+	parts []Doc
+}
+
+func (f *fill) String() string {
+	parts := make([]string, len(f.parts))
+	for i, part := range f.parts {
+		parts[i] = part.String()
+	}
+	return fmt.Sprintf("Fill(%v, [%v])", f.sep.String(), strings.Join(parts, ", "))
+}
+
+func (f *fill) flattenBool() (Doc, bool) {
+	flatSep, _ := f.sep.flattenBool()
+	parts := make([]Doc, len(f.parts))
+	for i, part := range f.parts {
+		parts[i], _ = part.flattenBool()
+	}
+	return Intercalate(flatSep, parts), true
+}
+
+type annotation struct {
+	value interface{}
+	doc   Doc
+}
+
+func (a *annotation) String() string {
+	return fmt.Sprintf("Annotate(%v, %v)", a.value, a.doc.String())
+}
+
+func (a *annotation) flattenBool() (Doc, bool) {
+	flattened, changed := a.doc.flattenBool()
+	return &annotation{
+		value: a.value,
+		doc:   flattened,
+	}, changed
+}
+
+type cursor struct{}
+
+func (c *cursor) String() string {
+	return "Cursor()"
+}
+
+func (c *cursor) flattenBool() (Doc, bool) {
+	return c, false
+}
+
+type lazyDoc struct {
+	once sync.Once
+	f    func() Doc
+	doc  Doc
+	// panicked is the value f panicked with.
+	panicked interface{}
+	// done is set to 1 after f is called.
+	done uint32
+}
+
+func (l *lazyDoc) String() string {
 	return "LazyDoc()"
 }
 
-func (l lazyDoc) flattenBool() (Doc, bool) {
-	f := <-l
-	l <- f
-	doc := f()
-	return doc.flattenBool()
+func (l *lazyDoc) flattenBool() (Doc, bool) {
+	return l.Evaluated().flattenBool()
+}
+
+// Evaluated returns the doc built by f, calling f only the first time.
+// If f panicked, Evaluated panics with the same value every time.
+func (l *lazyDoc) Evaluated() Doc {
+	l.once.Do(func() {
+		defer func() {
+			l.panicked = recover()
+			l.f = nil
+			atomic.StoreUint32(&l.done, 1)
+		}()
+		l.doc = l.f()
+	})
+	if l.panicked != nil {
+		panic(l.panicked)
+	}
+	return l.doc
 }
 
-func (l lazyDoc) Evaluated() Doc {
-	f := <-l
-	l <- f
-	return f()
+// evaluated reports whether the doc has been built,
+// without building it.
+func (l *lazyDoc) evaluated() bool {
+	return atomic.LoadUint32(&l.done) == 1
 }
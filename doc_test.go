@@ -2,6 +2,8 @@ package prettier
 
 import (
 	"reflect"
+	"sync"
+	"sync/atomic"
 	"testing"
 )
 
@@ -69,6 +71,14 @@ func TestUnionDoc(t *testing.T) {
 	}
 }
 
+func TestCursorDoc(t *testing.T) {
+	doc := Cursor()
+	flat, changed := doc.flattenBool()
+	if changed || !reflect.DeepEqual(doc, flat) {
+		t.Errorf("flatten(Cursor()) should not chage the doc")
+	}
+}
+
 func TestLazyDocEvaluate(t *testing.T) {
 	evaluated := false
 	f := func() Doc {
@@ -96,3 +106,62 @@ func TestLazyDocFlatten(t *testing.T) {
 		t.Errorf("lazydoc should be flattened to flattened evaluated doc")
 	}
 }
+
+func TestLazyDocMemoized(t *testing.T) {
+	calls := 0
+	lazydoc := lazy(func() Doc {
+		calls++
+		return Line()
+	})
+	first := lazydoc.Evaluated()
+	lazydoc.flattenBool()
+	second := lazydoc.Evaluated()
+	if calls != 1 {
+		t.Errorf("specified function should be called only once, but called %v times", calls)
+	}
+	if first != second {
+		t.Errorf("lazydoc should return the same doc every time")
+	}
+}
+
+func TestLazyDocPanic(t *testing.T) {
+	lazydoc := lazy(func() Doc {
+		panic("boom")
+	})
+	for i := 0; i < 2; i++ {
+		func() {
+			defer func() {
+				if r := recover(); r != "boom" {
+					t.Errorf("lazydoc should panic with the same value, actual: %v", r)
+				}
+			}()
+			lazydoc.Evaluated()
+		}()
+	}
+}
+
+func TestLazyDocConcurrent(t *testing.T) {
+	var calls int32
+	doc := Lazy(func() Doc {
+		atomic.AddInt32(&calls, 1)
+		return Fill(Concat([]Doc{Text(","), Line()}), []Doc{Text("foo"), Text("bar"), Text("baz")})
+	})
+	var wg sync.WaitGroup
+	results := make([]string, 8)
+	for i := range results {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			results[i] = Pretty(7, doc)
+		}(i)
+	}
+	wg.Wait()
+	if calls != 1 {
+		t.Errorf("specified function should be called only once, but called %v times", calls)
+	}
+	for _, result := range results {
+		if result != "foo,\nbar,\nbaz" {
+			t.Errorf("expected: %q, actual: %q", "foo,\nbar,\nbaz", result)
+		}
+	}
+}
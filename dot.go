@@ -0,0 +1,92 @@
+package prettier
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// DOT exports the given Doc as a graph in the DOT language of Graphviz.
+//
+// Each node of the Doc is drawn once even if it is shared, so that
+// the sharing between the branches of unions can be seen.
+// Lazy documents are not evaluated by DOT; those which have not been
+// evaluated yet are drawn as dashed nodes without children.
+func DOT(doc Doc) string {
+	g := &dotGraph{ids: map[Doc]string{}}
+	g.sb.WriteString("digraph Doc {\n")
+	g.sb.WriteString("  node [fontname=\"monospace\"];\n")
+	g.node(doc)
+	g.sb.WriteString("}\n")
+	return g.sb.String()
+}
+
+type dotGraph struct {
+	sb  strings.Builder
+	ids map[Doc]string
+}
+
+// node writes the node and its descendants, and returns its ID.
+func (g *dotGraph) node(doc Doc) string {
+	if id, ok := g.ids[doc]; ok {
+		return id
+	}
+	id := fmt.Sprintf("n%d", len(g.ids))
+	g.ids[doc] = id
+	label := NodeOf(doc).Kind.String()
+	attrs := ""
+	edges := []string{}
+	children := []Doc{}
+	switch v := doc.(type) {
+	case *text:
+		label = fmt.Sprintf("Text %s", strconv.Quote(v.str))
+		attrs = ", shape=box"
+	case *line:
+		if !v.flattenToSpace {
+			label = "LineBreak"
+		}
+	case *concat:
+		children = []Doc{v.a, v.b}
+	case *nest:
+		label = fmt.Sprintf("Nest %d", v.indent)
+		children = []Doc{v.doc}
+	case *group, *align:
+		children = Children(doc)
+	case *ifBreak:
+		children = []Doc{v.broken, v.flat}
+		edges = []string{"break", "flat"}
+	case *fill:
+		children = append([]Doc{v.sep}, v.parts...)
+		edges = []string{"sep"}
+	case *union:
+		attrs = ", shape=diamond"
+		children = []Doc{v.a, v.b}
+		edges = []string{"flat", "break"}
+	case *lazyDoc:
+		if v.evaluated() {
+			children = []Doc{v.Evaluated()}
+		} else {
+			label = "Lazy (unevaluated)"
+			attrs = ", style=dashed"
+		}
+	case *annotation:
+		label = fmt.Sprintf("Annotate %v", v.value)
+		children = []Doc{v.doc}
+	}
+	fmt.Fprintf(&g.sb, "  %s [label=%s%s];\n", id, dotQuote(label), attrs)
+	for i, child := range children {
+		childID := g.node(child)
+		if i < len(edges) {
+			fmt.Fprintf(&g.sb, "  %s -> %s [label=%s];\n", id, childID, dotQuote(edges[i]))
+		} else {
+			fmt.Fprintf(&g.sb, "  %s -> %s;\n", id, childID)
+		}
+	}
+	return id
+}
+
+func dotQuote(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, `"`, `\"`)
+	return `"` + s + `"`
+}
@@ -0,0 +1,80 @@
+package prettier
+
+import (
+	"testing"
+)
+
+func TestDOT(t *testing.T) {
+	sep := Concat([]Doc{Text(","), Line()})
+	doc := Concat([]Doc{
+		Annotate("id", Text(`"a"`)),
+		sep,
+		Nest(uint(2), Group(LineBreak())),
+		sep,
+		lazy(Cursor),
+	})
+	expected := `digraph Doc {
+  node [fontname="monospace"];
+  n0 [label="Concat"];
+  n1 [label="Annotate id"];
+  n2 [label="Text \"\\\"a\\\"\"", shape=box];
+  n1 -> n2;
+  n0 -> n1;
+  n3 [label="Concat"];
+  n4 [label="Concat"];
+  n5 [label="Text \",\"", shape=box];
+  n4 -> n5;
+  n6 [label="Line"];
+  n4 -> n6;
+  n3 -> n4;
+  n7 [label="Concat"];
+  n8 [label="Nest 2"];
+  n9 [label="Group"];
+  n10 [label="LineBreak"];
+  n9 -> n10;
+  n8 -> n9;
+  n7 -> n8;
+  n11 [label="Concat"];
+  n11 -> n4;
+  n12 [label="Lazy (unevaluated)", style=dashed];
+  n11 -> n12;
+  n7 -> n11;
+  n3 -> n7;
+  n0 -> n3;
+}
+`
+	if DOT(doc) != expected {
+		t.Errorf("expected: %v, actual: %v", expected, DOT(doc))
+	}
+}
+
+func TestDOTEvaluatedLazy(t *testing.T) {
+	doc := lazy(Cursor)
+	doc.Evaluated()
+	expected := `digraph Doc {
+  node [fontname="monospace"];
+  n0 [label="Lazy"];
+  n1 [label="Cursor"];
+  n0 -> n1;
+}
+`
+	if DOT(doc) != expected {
+		t.Errorf("expected: %v, actual: %v", expected, DOT(doc))
+	}
+}
+
+func TestDOTUnion(t *testing.T) {
+	doc := &union{a: Empty(), b: LineBreak()}
+	expected := `digraph Doc {
+  node [fontname="monospace"];
+  n0 [label="Union", shape=diamond];
+  n1 [label="Empty"];
+  n0 -> n1 [label="flat"];
+  n2 [label="LineBreak"];
+  n0 -> n2 [label="break"];
+}
+`
+	if DOT(doc) != expected {
+		t.Errorf("expected: %v, actual: %v", expected, DOT(doc))
+	}
+}
@@ -0,0 +1,187 @@
+package prettier
+
+import (
+	"encoding/binary"
+	"hash"
+	"hash/fnv"
+	"math"
+	"reflect"
+)
+
+// Equal reports whether the given documents have the same structure.
+// Lazy documents are evaluated when they are compared,
+// and annotations are compared with reflect.DeepEqual.
+func Equal(a Doc, b Doc) bool {
+	if a == b {
+		return true
+	}
+	if l, ok := a.(*lazyDoc); ok {
+		a = l.Evaluated()
+	}
+	if l, ok := b.(*lazyDoc); ok {
+		b = l.Evaluated()
+	}
+	switch x := a.(type) {
+	case *empty:
+		_, ok := b.(*empty)
+		return ok
+	case *text:
+		y, ok := b.(*text)
+		return ok && x.str == y.str && x.length == y.length
+	case *line:
+		y, ok := b.(*line)
+		return ok && x.flattenToSpace == y.flattenToSpace
+	case *concat:
+		y, ok := b.(*concat)
+		return ok && Equal(x.a, y.a) && Equal(x.b, y.b)
+	case *nest:
+		y, ok := b.(*nest)
+		return ok && x.indent == y.indent && Equal(x.doc, y.doc)
+	case *align:
+		y, ok := b.(*align)
+		return ok && Equal(x.doc, y.doc)
+	case *group:
+		y, ok := b.(*group)
+		return ok && Equal(x.doc, y.doc)
+	case *ifBreak:
+		y, ok := b.(*ifBreak)
+		return ok && Equal(x.broken, y.broken) && Equal(x.flat, y.flat)
+	case *fill:
+		y, ok := b.(*fill)
+		if !ok || len(x.parts) != len(y.parts) || !Equal(x.sep, y.sep) {
+			return false
+		}
+		for i := range x.parts {
+			if !Equal(x.parts[i], y.parts[i]) {
+				return false
+			}
+		}
+		return true
+	case *union:
+		y, ok := b.(*union)
+		return ok && Equal(x.a, y.a) && Equal(x.b, y.b)
+	case *annotation:
+		y, ok := b.(*annotation)
+		return ok && reflect.DeepEqual(x.value, y.value) && Equal(x.doc, y.doc)
+	case *cursor:
+		_, ok := b.(*cursor)
+		return ok
+	default:
+		return false
+	}
+}
+
+// Hash returns a hash of the structure of the given Doc, which is stable
+// across processes. Documents which are Equal have the same hash.
+// Lazy documents are evaluated to compute the hash.
+func Hash(doc Doc) uint64 {
+	h := fnv.New64a()
+	hashDoc(h, doc)
+	return h.Sum64()
+}
+
+func hashDoc(h hash.Hash64, doc Doc) {
+	if l, ok := doc.(*lazyDoc); ok {
+		doc = l.Evaluated()
+	}
+	node := NodeOf(doc)
+	writeUint64(h, uint64(node.Kind))
+	switch node.Kind {
+	case KindText:
+		writeString(h, node.Text)
+		writeUint64(h, uint64(node.Length))
+	case KindLine:
+		if node.FlattenToSpace {
+			writeUint64(h, 1)
+		} else {
+			writeUint64(h, 0)
+		}
+	case KindNest:
+		writeUint64(h, uint64(node.Indent))
+	case KindAnnotation:
+		writeUint64(h, hashValue(reflect.ValueOf(node.Annotation), 0))
+	}
+	children := Children(doc)
+	if node.Kind == KindFill {
+		// fills have a variable number of children.
+		writeUint64(h, uint64(len(children)))
+	}
+	for _, child := range children {
+		hashDoc(h, child)
+	}
+}
+
+func writeUint64(h hash.Hash64, n uint64) {
+	var buf [8]byte
+	binary.LittleEndian.PutUint64(buf[:], n)
+	h.Write(buf[:])
+}
+
+// writeFloat64 writes the bits of f, where -0 is written as 0 since they
+// are equal.
+func writeFloat64(h hash.Hash64, f float64) {
+	if f == 0 {
+		f = 0
+	}
+	writeUint64(h, math.Float64bits(f))
+}
+
+func writeString(h hash.Hash64, s string) {
+	writeUint64(h, uint64(len(s)))
+	h.Write([]byte(s))
+}
+
+// maxHashDepth limits how deep hashValue looks into values,
+// so that cyclic values can be hashed.
+const maxHashDepth = 8
+
+// hashValue hashes a value consistently with reflect.DeepEqual.
+func hashValue(v reflect.Value, depth int) uint64 {
+	h := fnv.New64a()
+	if !v.IsValid() {
+		return h.Sum64()
+	}
+	writeString(h, v.Type().String())
+	if depth > maxHashDepth {
+		return h.Sum64()
+	}
+	switch v.Kind() {
+	case reflect.Bool:
+		if v.Bool() {
+			writeUint64(h, 1)
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		writeUint64(h, uint64(v.Int()))
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		writeUint64(h, v.Uint())
+	case reflect.Float32, reflect.Float64:
+		writeFloat64(h, v.Float())
+	case reflect.Complex64, reflect.Complex128:
+		writeFloat64(h, real(v.Complex()))
+		writeFloat64(h, imag(v.Complex()))
+	case reflect.String:
+		writeString(h, v.String())
+	case reflect.Array, reflect.Slice:
+		writeUint64(h, uint64(v.Len()))
+		for i := 0; i < v.Len(); i++ {
+			writeUint64(h, hashValue(v.Index(i), depth+1))
+		}
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			writeUint64(h, hashValue(v.Field(i), depth+1))
+		}
+	case reflect.Map:
+		// entries are combined regardless of the order of iteration.
+		var sum uint64
+		iter := v.MapRange()
+		for iter.Next() {
+			sum += hashValue(iter.Key(), depth+1)*31 + hashValue(iter.Value(), depth+1)
+		}
+		writeUint64(h, sum)
+	case reflect.Ptr, reflect.Interface:
+		if !v.IsNil() {
+			writeUint64(h, hashValue(v.Elem(), depth+1))
+		}
+	}
+	return h.Sum64()
+}
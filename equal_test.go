@@ -0,0 +1,71 @@
+package prettier
+
+import (
+	"math"
+	"testing"
+)
+
+func TestEqual(t *testing.T) {
+	sep := Concat([]Doc{Text(","), Line()})
+	parts := []Doc{Text("a"), Text("b"), Text("c")}
+	tests := []struct {
+		a        Doc
+		b        Doc
+		expected bool
+	}{
+		{Empty(), Empty(), true},
+		{Text("a"), Text("a"), true},
+		{Text("a"), TextWithLength("a", 2), false},
+		{Line(), LineBreak(), false},
+		{Concat([]Doc{Text("a"), Line()}), Concat([]Doc{Text("a"), Line()}), true},
+		{Concat([]Doc{Text("a"), Line()}), Concat([]Doc{Text("a"), Text(" ")}), false},
+		{Nest(uint(2), Line()), Nest(uint(3), Line()), false},
+		{Group(Line()), Group(Line()), true},
+		{Fill(sep, parts), Fill(sep, parts), true},
+		{lazy(Line), Line(), true},
+		{Line(), lazy(Line), true},
+		{Annotate(map[string]int{"a": 1}, Line()), Annotate(map[string]int{"a": 1}, Line()), true},
+		{Annotate(map[string]int{"a": 1}, Line()), Annotate(map[string]int{"a": 2}, Line()), false},
+		{Cursor(), Cursor(), true},
+		{Cursor(), Empty(), false},
+		{Annotate(0.0, Line()), Annotate(math.Copysign(0, -1), Line()), true},
+		{Annotate(complex(0, 0), Line()), Annotate(complex(math.Copysign(0, -1), 0), Line()), true},
+	}
+	for _, tt := range tests {
+		if Equal(tt.a, tt.b) != tt.expected {
+			t.Errorf("Equal(%v, %v) should be %v", tt.a, tt.b, tt.expected)
+		}
+		if tt.expected && Hash(tt.a) != Hash(tt.b) {
+			t.Errorf("Hash(%v) should be equal to Hash(%v)", tt.a, tt.b)
+		}
+	}
+}
+
+func TestHash(t *testing.T) {
+	docs := []Doc{
+		Empty(),
+		Text("a"),
+		Text("b"),
+		Line(),
+		LineBreak(),
+		Concat([]Doc{Text("a"), Text("b")}),
+		Concat([]Doc{Text("b"), Text("a")}),
+		Nest(uint(2), Text("a")),
+		Group(Line()),
+		Annotate("a", Text("a")),
+		Annotate("b", Text("a")),
+		Cursor(),
+	}
+	hashes := map[uint64]Doc{}
+	for _, doc := range docs {
+		h := Hash(doc)
+		if other, ok := hashes[h]; ok {
+			t.Errorf("%v and %v have the same hash", doc, other)
+		}
+		hashes[h] = doc
+	}
+	// the hash must not depend on the process
+	if Hash(Text("a")) != 0x28b397c3db0406cd {
+		t.Errorf("unexpected hash: %x", Hash(Text("a")))
+	}
+}
@@ -0,0 +1,67 @@
+package prettier
+
+import (
+	"fmt"
+	"io"
+)
+
+// Prettier is implemented by values which know how to lay themselves out
+// as a Doc.
+type Prettier interface {
+	PrettyDoc() Doc
+}
+
+// DocOf returns the Doc of the given value, so that any value can be
+// put in documents built by the combinators. It returns the value itself
+// if it is a Doc, the result of PrettyDoc if it implements Prettier,
+// and the text formatted by fmt.Sprint otherwise.
+func DocOf(v interface{}) Doc {
+	switch v := v.(type) {
+	case Doc:
+		return v
+	case Prettier:
+		return v.PrettyDoc()
+	default:
+		return Text(fmt.Sprint(v))
+	}
+}
+
+// Format renders the Doc of p for the verbs %v and %s, limiting line
+// lengths to the width of the verb (80 if it is not given) when possible.
+// It is intended to implement fmt.Formatter of a type which implements
+// Prettier:
+//
+//	func (t T) Format(f fmt.State, verb rune) {
+//		prettier.Format(f, verb, t)
+//	}
+//
+// so that `fmt.Printf("%40v", t)` renders t within 40 columns. The other
+// verbs are bad verbs, which are written like `%!d(T=value)` as fmt does.
+func Format(f fmt.State, verb rune, p Prettier) {
+	switch verb {
+	case 'v', 's':
+		width, ok := f.Width()
+		if !ok {
+			width = 80
+		}
+		_, _ = io.WriteString(f, Pretty(width, p.PrettyDoc()))
+	default:
+		// like fmt, the bad verb is written with the type and the value.
+		fmt.Fprintf(f, "%%!%c(%T=%s)", verb, p, Pretty(80, p.PrettyDoc()))
+	}
+}
+
+// Formatter returns a fmt.Formatter which formats p with Format.
+// This is useful for types which don't implement fmt.Formatter, e.g.
+// `fmt.Printf("%40v", prettier.Formatter(t))`.
+func Formatter(p Prettier) fmt.Formatter {
+	return formatter{p: p}
+}
+
+type formatter struct {
+	p Prettier
+}
+
+func (f formatter) Format(s fmt.State, verb rune) {
+	Format(s, verb, f.p)
+}
@@ -0,0 +1,68 @@
+package prettier
+
+import (
+	"fmt"
+	"testing"
+)
+
+type list []string
+
+func (l list) PrettyDoc() Doc {
+	ds := make([]Doc, len(l))
+	for i, s := range l {
+		ds[i] = Text(s)
+	}
+	return TightBracketBy(Text("["), Text("]"), Intercalate(Concat([]Doc{Text(","), Line()}), ds), uint(2))
+}
+
+func (l list) Format(f fmt.State, verb rune) {
+	Format(f, verb, l)
+}
+
+func TestDocOf(t *testing.T) {
+	doc := Text("a")
+	if DocOf(doc) != doc {
+		t.Errorf("DocOf(doc) should be the doc")
+	}
+	if Pretty(80, DocOf(list{"a", "b"})) != "[a, b]" {
+		t.Errorf("expected: %v, actual: %v", "[a, b]", Pretty(80, DocOf(list{"a", "b"})))
+	}
+	if Pretty(80, DocOf(42)) != "42" {
+		t.Errorf("expected: %v, actual: %v", "42", Pretty(80, DocOf(42)))
+	}
+}
+
+func TestFormat(t *testing.T) {
+	l := list{"foo", "bar"}
+	tests := []struct {
+		format   string
+		expected string
+	}{
+		{"%v", "[foo, bar]"},
+		{"%s", "[foo, bar]"},
+		{"%5v", "[\n  foo,\n  bar\n]"},
+		{"%d", "%!d(prettier.list=[foo, bar])"},
+		{"%q", "%!q(prettier.list=[foo, bar])"},
+	}
+	for _, tt := range tests {
+		if actual := fmt.Sprintf(tt.format, l); actual != tt.expected {
+			t.Errorf("expected: %q, actual: %q", tt.expected, actual)
+		}
+	}
+}
+
+type names []string
+
+func (n names) PrettyDoc() Doc {
+	return list(n).PrettyDoc()
+}
+
+func TestFormatter(t *testing.T) {
+	n := names{"foo", "bar"}
+	if actual := fmt.Sprintf("%v", n); actual != "[foo bar]" {
+		t.Errorf("expected: %q, actual: %q", "[foo bar]", actual)
+	}
+	if actual := fmt.Sprintf("%5v", Formatter(n)); actual != "[\n  foo,\n  bar\n]" {
+		t.Errorf("expected: %q, actual: %q", "[\n  foo,\n  bar\n]", actual)
+	}
+}
@@ -0,0 +1,154 @@
+package goformat
+
+import (
+	"go/ast"
+	"go/token"
+	"sort"
+	"strings"
+
+	p "github.com/tanishiking/prettier"
+)
+
+// group lays out the comments of the group. The comments on separate
+// lines are kept on separate lines.
+func (pr *printer) group(g *ast.CommentGroup) p.Doc {
+	pr.printed[g] = true
+	ds := []p.Doc{}
+	for i, c := range g.List {
+		if i > 0 {
+			if pr.line(c.Pos()) == pr.line(g.List[i-1].End()) {
+				ds = append(ds, p.Text(" "))
+			} else {
+				ds = append(ds, p.LineBreak())
+			}
+		}
+		if strings.HasPrefix(c.Text, "//") {
+			// nothing follows a line comment on its line, which can't
+			// be broken to fit the width.
+			ds = append(ds, p.TextWithLength(c.Text, 0))
+			continue
+		}
+		ds = append(ds, raw(c.Text))
+	}
+	return p.Concat(ds)
+}
+
+// trail lays out the comments associated with the node which are after
+// the node and before end, and the comments in the node which are not laid
+// out yet.
+// The comments on the line of the end of the node follow a space, and
+// the rest of the comments are on separate lines.
+func (pr *printer) trail(n ast.Node, end token.Pos) p.Doc {
+	groups := []*ast.CommentGroup{}
+	for _, g := range pr.comments[pr.next(n.Pos()):] {
+		if g.Pos() >= n.End() {
+			break
+		}
+		if !pr.printed[g] && g.Pos() >= n.Pos() {
+			groups = append(groups, g)
+		}
+	}
+	for _, g := range pr.cmap[n] {
+		if !pr.printed[g] && g.Pos() >= n.End() && g.Pos() < end {
+			groups = append(groups, g)
+		}
+	}
+	ds := []p.Doc{}
+	last := n.End()
+	for i, g := range groups {
+		if pr.line(g.Pos()) <= pr.line(last) && (i == 0 || !isLine(groups[i-1])) {
+			ds = append(ds, p.Text(" "))
+		} else {
+			ds = append(ds, p.LineBreak())
+		}
+		ds = append(ds, pr.group(g))
+		if g.End() > last {
+			last = g.End()
+		}
+	}
+	pr.lastEnd = last
+	return p.Concat(ds)
+}
+
+// isLine reports whether the group ends with a line comment.
+func isLine(g *ast.CommentGroup) bool {
+	return strings.HasPrefix(g.List[len(g.List)-1].Text, "//")
+}
+
+// before lays out the comments before pos which are not laid out yet.
+// A comment on the line of the last node laid out follows a space, and the
+// rest of the comments are preceded by a line break. The blank lines
+// before the comments are kept.
+func (pr *printer) before(pos token.Pos) p.Doc {
+	for pr.unprinted < len(pr.comments) && pr.printed[pr.comments[pr.unprinted]] {
+		pr.unprinted++
+	}
+	ds := []p.Doc{}
+	for _, g := range pr.comments[pr.unprinted:] {
+		if g.Pos() >= pos {
+			break
+		}
+		if pr.printed[g] {
+			continue
+		}
+		switch {
+		case !pr.lastEnd.IsValid():
+			// the first comment of the file.
+		case pr.line(g.Pos()) == pr.line(pr.lastEnd):
+			ds = append(ds, p.Text(" "))
+		case pr.line(g.Pos())-pr.line(pr.lastEnd) > 1:
+			ds = append(ds, p.LineBreak(), p.LineBreak())
+		default:
+			ds = append(ds, p.LineBreak())
+		}
+		ds = append(ds, pr.group(g))
+		pr.lastEnd = g.End()
+	}
+	return p.Concat(ds)
+}
+
+// inline lays out the comments between end and pos, which are on the line
+// of end since a line break after end would end the statement.
+func (pr *printer) inline(end token.Pos, pos token.Pos) p.Doc {
+	if !pr.hasComment(end, pos) {
+		return p.Empty()
+	}
+	pr.lastEnd = end
+	return pr.before(pos)
+}
+
+// hasComment reports whether a comment is in the range.
+func (pr *printer) hasComment(pos token.Pos, end token.Pos) bool {
+	i := pr.next(pos)
+	return i < len(pr.comments) && pr.comments[i].Pos() < end
+}
+
+// next is the index of the first comment which ends after pos.
+func (pr *printer) next(pos token.Pos) int {
+	return sort.Search(len(pr.comments), func(i int) bool {
+		return pr.comments[i].End() > pos
+	})
+}
+
+// isHard reports whether the lines in the node are always broken: it has
+// comments, or function bodies, struct types or interface types which
+// are laid out in lines.
+func (pr *printer) isHard(n ast.Node) bool {
+	if h, ok := pr.hard[n]; ok {
+		return h
+	}
+	h := pr.hasComment(n.Pos(), n.End())
+	ast.Inspect(n, func(c ast.Node) bool {
+		switch c := c.(type) {
+		case *ast.FuncLit:
+			h = h || len(c.Body.List) > 0
+		case *ast.StructType:
+			h = h || len(c.Fields.List) > 0
+		case *ast.InterfaceType:
+			h = h || len(c.Methods.List) > 0
+		}
+		return !h
+	})
+	pr.hard[n] = h
+	return h
+}
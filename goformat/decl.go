@@ -0,0 +1,336 @@
+package goformat
+
+import (
+	"go/ast"
+	"go/token"
+
+	p "github.com/tanishiking/prettier"
+)
+
+func (pr *printer) file(f *ast.File) p.Doc {
+	ds := []p.Doc{}
+	if pr.hasComment(token.NoPos, f.Package) {
+		ds = append(ds, pr.before(f.Package), p.LineBreak())
+		if pr.line(f.Package)-pr.line(pr.lastEnd) > 1 {
+			ds = append(ds, p.LineBreak())
+		}
+	}
+	ds = append(ds, p.Text("package "+f.Name.Name))
+	pr.lastEnd = f.Name.End()
+	decls := make([]ast.Node, len(f.Decls))
+	for i, d := range f.Decls {
+		decls[i] = d
+	}
+	end := token.Pos(pr.fset.Base())
+	next := end
+	if len(decls) > 0 {
+		next = decls[0].Pos()
+	}
+	if i := pr.next(pr.lastEnd); i < len(pr.comments) && pr.comments[i].Pos() < next {
+		next = pr.comments[i].Pos()
+	}
+	if next.IsValid() && pr.line(next) == pr.line(pr.lastEnd)+1 {
+		// a blank line always follows the package clause.
+		ds = append(ds, p.LineBreak())
+	}
+	ds = append(ds, pr.lines(decls, end, func(n ast.Node) p.Doc {
+		return pr.decl(n.(ast.Decl))
+	}, false))
+	ds = append(ds, pr.before(end))
+	return p.Concat(ds)
+}
+
+// lines lays out the nodes on separate lines with the comments before and
+// after them until end, keeping the blank lines between them. The first
+// node starts with a line break.
+// The nodes are indented if nested is true, except for the labels of
+// labeled statements, which are indented a level less than the statements
+// like gofmt.
+func (pr *printer) lines(nodes []ast.Node, end token.Pos, layout func(ast.Node) p.Doc, nested bool) p.Doc {
+	ds := []p.Doc{}
+	for i, n := range nodes {
+		comments := pr.before(n.Pos())
+		breaks := p.LineBreak()
+		if pr.line(n.Pos())-pr.line(pr.lastEnd) > 1 {
+			breaks = p.Concat([]p.Doc{breaks, p.LineBreak()})
+		}
+		next := end
+		if i+1 < len(nodes) {
+			next = nodes[i+1].Pos()
+		}
+		doc := p.Concat([]p.Doc{layout(n), pr.trail(n, next)})
+		if _, ok := n.(*ast.LabeledStmt); ok && nested {
+			ds = append(ds, pr.nest(comments), breaks, pr.nest(doc))
+		} else if nested {
+			ds = append(ds, pr.nest(p.Concat([]p.Doc{comments, breaks, doc})))
+		} else {
+			ds = append(ds, comments, breaks, doc)
+		}
+	}
+	return p.Concat(ds)
+}
+
+// list lays out the elements between the brackets. They are on one line
+// if they fit, and otherwise each of them is on its own line followed by
+// a comma. If the last element is a function or composite literal which
+// is laid out in lines, the rest of the elements are kept on the first
+// line.
+func (pr *printer) list(open string, elems []ast.Node, layout func(int, ast.Node) p.Doc, close string, openPos token.Pos, closePos token.Pos) p.Doc {
+	return pr.brackets(open, elems, layout, close, openPos, closePos, false)
+}
+
+// brackets lays out the elements like list. The last element is followed
+// by a comma even on one line if comma is true.
+func (pr *printer) brackets(open string, elems []ast.Node, layout func(int, ast.Node) p.Doc, close string, openPos token.Pos, closePos token.Pos, comma bool) p.Doc {
+	if len(elems) == 0 && !pr.hasComment(openPos, closePos) {
+		return p.Text(open + close)
+	}
+	hard := pr.hasComment(openPos, closePos)
+	for _, e := range elems {
+		hard = hard || pr.isHard(e)
+	}
+	if len(elems) > 0 && hard && pr.hugs(elems[len(elems)-1], elems[:len(elems)-1], openPos, closePos) {
+		ds := []p.Doc{p.Text(open)}
+		for i, e := range elems {
+			if i > 0 {
+				ds = append(ds, p.Text(", "))
+			}
+			ds = append(ds, layout(i, e))
+		}
+		ds = append(ds, p.Text(close))
+		return p.Concat(ds)
+	}
+	if !hard && len(elems) == 1 && simple(elems[0]) {
+		// breaking the element into a line doesn't shorten the line much.
+		if comma {
+			close = "," + close
+		}
+		return p.Concat([]p.Doc{p.Text(open), layout(0, elems[0]), p.Text(close)})
+	}
+	if !hard {
+		ds := []p.Doc{}
+		for i, e := range elems {
+			if i > 0 {
+				ds = append(ds, p.Line())
+			}
+			ds = append(ds, layout(i, e), p.Text(","))
+		}
+		if !comma {
+			ds[len(ds)-1] = p.IfBreak(p.Text(","), p.Empty())
+		}
+		return p.TightBracketBy(p.Text(open), p.Text(close), p.Concat(ds), pr.opts.Indent)
+	}
+	index := map[ast.Node]int{}
+	for i, e := range elems {
+		index[e] = i
+	}
+	pr.lastEnd = openPos
+	body := pr.lines(elems, closePos, func(n ast.Node) p.Doc {
+		return p.Concat([]p.Doc{layout(index[n], n), p.Text(",")})
+	}, false)
+	return p.Concat([]p.Doc{
+		p.Text(open),
+		pr.nest(p.Concat([]p.Doc{body, pr.before(closePos)})),
+		p.LineBreak(),
+		p.Text(close),
+	})
+}
+
+// simple reports whether the node is a name, a literal or a short
+// expression of them.
+func simple(n ast.Node) bool {
+	switch n := n.(type) {
+	case *ast.Ident, *ast.BasicLit:
+		return true
+	case *ast.Field:
+		return simple(n.Type)
+	case *ast.SelectorExpr:
+		return simple(n.X)
+	case *ast.StarExpr:
+		return simple(n.X)
+	case *ast.UnaryExpr:
+		return simple(n.X)
+	case *ast.Ellipsis:
+		return n.Elt == nil || simple(n.Elt)
+	case *ast.BinaryExpr:
+		return n.Op != token.LAND && n.Op != token.LOR && simple(n.X) && simple(n.Y)
+	}
+	return false
+}
+
+// hugs reports whether the last element of a list is laid out in lines
+// after the rest of the elements on the first line.
+func (pr *printer) hugs(last ast.Node, rest []ast.Node, openPos token.Pos, closePos token.Pos) bool {
+	x := last
+	if u, ok := x.(*ast.UnaryExpr); ok && u.Op == token.AND {
+		x = u.X
+	}
+	// the comments in the body are laid out in the body.
+	var body token.Pos
+	switch x := x.(type) {
+	case *ast.FuncLit:
+		body = x.Body.Lbrace
+	case *ast.CompositeLit:
+		body = x.Lbrace
+	default:
+		return false
+	}
+	for _, e := range rest {
+		if pr.isHard(e) {
+			return false
+		}
+	}
+	return !pr.hasComment(openPos, body) && !pr.hasComment(last.End(), closePos)
+}
+
+func (pr *printer) decl(d ast.Decl) p.Doc {
+	switch d := d.(type) {
+	case *ast.GenDecl:
+		return pr.genDecl(d)
+	case *ast.FuncDecl:
+		ds := []p.Doc{p.Text("func ")}
+		if d.Recv != nil {
+			ds = append(ds, pr.fields(d.Recv), p.Text(" "))
+		}
+		ds = append(ds, p.Text(d.Name.Name))
+		if tparams := typeParams(d.Type); tparams != nil {
+			ds = append(ds, pr.typeParams(tparams, false))
+		}
+		ds = append(ds, pr.signature(d.Type))
+		if d.Body != nil {
+			ds = append(ds, p.Text(" "), pr.block(d.Body))
+		}
+		return p.Concat(ds)
+	}
+	return p.Empty()
+}
+
+func (pr *printer) genDecl(d *ast.GenDecl) p.Doc {
+	keyword := p.Text(d.Tok.String() + " ")
+	if !d.Lparen.IsValid() {
+		return p.Concat([]p.Doc{keyword, pr.spec(d.Specs[0])})
+	}
+	if len(d.Specs) == 0 && !pr.hasComment(d.Lparen, d.Rparen) {
+		return p.Concat([]p.Doc{keyword, p.Text("()")})
+	}
+	specs := make([]ast.Node, len(d.Specs))
+	for i, s := range d.Specs {
+		specs[i] = s
+	}
+	pr.lastEnd = d.Lparen
+	body := pr.lines(specs, d.Rparen, func(n ast.Node) p.Doc {
+		return pr.spec(n.(ast.Spec))
+	}, false)
+	return p.Concat([]p.Doc{
+		keyword,
+		p.Text("("),
+		pr.nest(p.Concat([]p.Doc{body, pr.before(d.Rparen)})),
+		p.LineBreak(),
+		p.Text(")"),
+	})
+}
+
+func (pr *printer) spec(s ast.Spec) p.Doc {
+	switch s := s.(type) {
+	case *ast.ImportSpec:
+		if s.Name != nil {
+			return p.Concat([]p.Doc{p.Text(s.Name.Name + " "), raw(s.Path.Value)})
+		}
+		return raw(s.Path.Value)
+	case *ast.ValueSpec:
+		ds := []p.Doc{pr.idents(s.Names)}
+		if s.Type != nil {
+			ds = append(ds, p.Text(" "), pr.expr(s.Type))
+		}
+		if len(s.Values) > 0 {
+			ds = append(ds, p.Text(" = "), pr.exprs(s.Values))
+		}
+		return p.Concat(ds)
+	case *ast.TypeSpec:
+		ds := []p.Doc{p.Text(s.Name.Name)}
+		if tparams := typeParams(s); tparams != nil {
+			ds = append(ds, pr.typeParams(tparams, true))
+		}
+		if s.Assign.IsValid() {
+			ds = append(ds, p.Text(" ="))
+		}
+		ds = append(ds, p.Text(" "), pr.expr(s.Type))
+		return p.Concat(ds)
+	}
+	return p.Empty()
+}
+
+// typeParams lays out the type parameters. A type declaration with
+// a parameter whose constraint could be read as an expression, such as
+// [P *C], needs a trailing comma.
+func (pr *printer) typeParams(fields *ast.FieldList, decl bool) p.Doc {
+	comma := false
+	if decl && len(fields.List) == 1 && len(fields.List[0].Names) == 1 {
+		switch fields.List[0].Type.(type) {
+		case *ast.Ident, *ast.SelectorExpr:
+		default:
+			comma = true
+		}
+	}
+	return pr.brackets("[", fieldNodes(fields), pr.field, "]", fields.Opening, fields.Closing, comma)
+}
+
+// signature lays out the parameters and the results of the function type.
+func (pr *printer) signature(t *ast.FuncType) p.Doc {
+	ds := []p.Doc{pr.fields(t.Params)}
+	if t.Results == nil {
+		return ds[0]
+	}
+	ds = append(ds, p.Text(" "))
+	r := t.Results
+	switch {
+	case len(r.List) == 1 && len(r.List[0].Names) == 0 && !r.Opening.IsValid():
+		ds = append(ds, pr.expr(r.List[0].Type))
+	case len(t.Params.List) > 0 && !pr.hasComment(r.Opening, r.Closing) && !pr.isHard(r):
+		// the parameters are broken into lines before the results.
+		fields := make([]p.Doc, len(r.List))
+		for i, f := range r.List {
+			fields[i] = pr.field(i, f)
+		}
+		ds = append(ds, p.Text("("), p.Intercalate(p.Text(", "), fields), p.Text(")"))
+	default:
+		ds = append(ds, pr.fields(r))
+	}
+	return p.Concat(ds)
+}
+
+// fields lays out the parameters, the results or the receiver in
+// parentheses.
+func (pr *printer) fields(fields *ast.FieldList) p.Doc {
+	return pr.list("(", fieldNodes(fields), pr.field, ")", fields.Opening, fields.Closing)
+}
+
+func fieldNodes(fields *ast.FieldList) []ast.Node {
+	nodes := make([]ast.Node, len(fields.List))
+	for i, f := range fields.List {
+		nodes[i] = f
+	}
+	return nodes
+}
+
+// field lays out the names and the type of a parameter or a struct field.
+func (pr *printer) field(_ int, n ast.Node) p.Doc {
+	f := n.(*ast.Field)
+	ds := []p.Doc{}
+	if len(f.Names) > 0 {
+		ds = append(ds, pr.idents(f.Names), p.Text(" "))
+	}
+	ds = append(ds, pr.expr(f.Type))
+	if f.Tag != nil {
+		ds = append(ds, p.Text(" "), raw(f.Tag.Value))
+	}
+	return p.Concat(ds)
+}
+
+func (pr *printer) idents(idents []*ast.Ident) p.Doc {
+	ds := make([]p.Doc, len(idents))
+	for i, id := range idents {
+		ds[i] = p.Text(id.Name)
+	}
+	return p.Intercalate(p.Text(", "), ds)
+}
@@ -0,0 +1,331 @@
+package goformat
+
+import (
+	"go/ast"
+	"go/token"
+
+	p "github.com/tanishiking/prettier"
+)
+
+// exprs lays out the expressions separated by commas, such as the left
+// and the right hand sides of an assignment.
+func (pr *printer) exprs(xs []ast.Expr) p.Doc {
+	ds := make([]p.Doc, len(xs))
+	for i, x := range xs {
+		ds[i] = pr.expr(x)
+	}
+	return p.Intercalate(p.Text(", "), ds)
+}
+
+func exprNodes(xs []ast.Expr) []ast.Node {
+	nodes := make([]ast.Node, len(xs))
+	for i, x := range xs {
+		nodes[i] = x
+	}
+	return nodes
+}
+
+func (pr *printer) elem(_ int, n ast.Node) p.Doc {
+	return pr.expr(n.(ast.Expr))
+}
+
+func (pr *printer) expr(x ast.Expr) p.Doc {
+	switch x := x.(type) {
+	case *ast.Ident:
+		return p.Text(x.Name)
+	case *ast.BasicLit:
+		return raw(x.Value)
+	case *ast.Ellipsis:
+		if x.Elt == nil {
+			return p.Text("...")
+		}
+		return p.Concat([]p.Doc{p.Text("..."), pr.expr(x.Elt)})
+	case *ast.FuncLit:
+		return p.Concat([]p.Doc{p.Text("func"), pr.signature(x.Type), p.Text(" "), pr.block(x.Body)})
+	case *ast.CompositeLit:
+		ds := []p.Doc{}
+		if x.Type != nil {
+			ds = append(ds, pr.expr(x.Type), pr.inline(x.Type.End(), x.Lbrace))
+		}
+		elem := func(_ int, n ast.Node) p.Doc {
+			return pr.at(1, n.(ast.Expr))
+		}
+		ds = append(ds, pr.list("{", exprNodes(x.Elts), elem, "}", x.Lbrace, x.Rbrace))
+		return p.Concat(ds)
+	case *ast.ParenExpr:
+		depth := pr.depth - 1
+		if depth < 1 {
+			depth = 1
+		}
+		return p.Concat([]p.Doc{p.Text("("), pr.at(depth, x.X), p.Text(")")})
+	case *ast.SelectorExpr:
+		return p.Concat([]p.Doc{pr.expr(x.X), p.Text("." + x.Sel.Name)})
+	case *ast.IndexExpr:
+		index := func(_ int, n ast.Node) p.Doc {
+			return pr.at(pr.depth+1, n.(ast.Expr))
+		}
+		return p.Concat([]p.Doc{
+			pr.at(1, x.X),
+			pr.inline(x.X.End(), x.Lbrack),
+			pr.list("[", []ast.Node{x.Index}, index, "]", x.Lbrack, x.Rbrack),
+		})
+	case *ast.SliceExpr:
+		indices := []ast.Expr{x.Low, x.High}
+		if x.Slice3 {
+			indices = append(indices, x.Max)
+		}
+		// like gofmt, the colons are spaced when the indices have binary
+		// operators, such as s[a+1 : b].
+		count, binaries := 0, 0
+		for _, index := range indices {
+			if index != nil {
+				count++
+				if _, ok := index.(*ast.BinaryExpr); ok {
+					binaries++
+				}
+			}
+		}
+		blanks := pr.depth <= 1 && count > 1 && binaries > 0
+		ds := []p.Doc{pr.at(1, x.X), p.Text("[")}
+		for i, index := range indices {
+			if i > 0 {
+				if indices[i-1] != nil && blanks {
+					ds = append(ds, p.Text(" "))
+				}
+				ds = append(ds, p.Text(":"))
+				if index != nil && blanks {
+					ds = append(ds, p.Text(" "))
+				}
+			}
+			if index != nil {
+				ds = append(ds, pr.at(pr.depth+1, index))
+			}
+		}
+		ds = append(ds, p.Text("]"))
+		return p.Concat(ds)
+	case *ast.TypeAssertExpr:
+		typ := p.Text("type")
+		if x.Type != nil {
+			typ = pr.expr(x.Type)
+		}
+		return p.Concat([]p.Doc{pr.expr(x.X), p.Text(".("), typ, p.Text(")")})
+	case *ast.CallExpr:
+		saved := pr.depth
+		if len(x.Args) > 1 {
+			pr.depth++
+		}
+		defer func() { pr.depth = saved }()
+		args := exprNodes(x.Args)
+		layout := pr.elem
+		if x.Ellipsis.IsValid() {
+			layout = func(i int, n ast.Node) p.Doc {
+				if i == len(args)-1 {
+					if lit, ok := n.(*ast.BasicLit); ok && lit.Kind == token.INT {
+						// 0... is not 0 ...
+						return p.Concat([]p.Doc{pr.expr(lit), p.Text(" ...")})
+					}
+					return p.Concat([]p.Doc{pr.expr(n.(ast.Expr)), p.Text("...")})
+				}
+				return pr.expr(n.(ast.Expr))
+			}
+		}
+		return p.Concat([]p.Doc{
+			pr.expr(x.Fun),
+			pr.inline(x.Fun.End(), x.Lparen),
+			pr.list("(", args, layout, ")", x.Lparen, x.Rparen),
+		})
+	case *ast.StarExpr:
+		return p.Concat([]p.Doc{p.Text("*"), pr.expr(x.X)})
+	case *ast.UnaryExpr:
+		op := x.Op.String()
+		if y, ok := x.X.(*ast.UnaryExpr); ok && joins(x.Op, y.Op) {
+			// - -x is not --x.
+			op += " "
+		}
+		return p.Concat([]p.Doc{p.Text(op), pr.expr(x.X)})
+	case *ast.BinaryExpr:
+		return pr.chain(x)
+	case *ast.KeyValueExpr:
+		return p.Concat([]p.Doc{pr.at(1, x.Key), p.Text(": "), pr.at(1, x.Value)})
+	case *ast.ArrayType:
+		ds := []p.Doc{p.Text("[")}
+		if x.Len != nil {
+			ds = append(ds, pr.expr(x.Len))
+		}
+		ds = append(ds, p.Text("]"), pr.expr(x.Elt))
+		return p.Concat(ds)
+	case *ast.StructType:
+		if len(x.Fields.List) == 0 && !pr.hasComment(x.Fields.Opening, x.Fields.Closing) {
+			return p.Text("struct{}")
+		}
+		return p.Concat([]p.Doc{
+			p.Text("struct "),
+			pr.braces(x.Fields.Opening, fieldNodes(x.Fields), func(n ast.Node) p.Doc {
+				return pr.field(0, n)
+			}, x.Fields.Closing, true),
+		})
+	case *ast.FuncType:
+		return p.Concat([]p.Doc{p.Text("func"), pr.signature(x)})
+	case *ast.InterfaceType:
+		if len(x.Methods.List) == 0 && !pr.hasComment(x.Methods.Opening, x.Methods.Closing) {
+			return p.Text("interface{}")
+		}
+		return p.Concat([]p.Doc{
+			p.Text("interface "),
+			pr.braces(x.Methods.Opening, fieldNodes(x.Methods), pr.member, x.Methods.Closing, true),
+		})
+	case *ast.MapType:
+		return p.Concat([]p.Doc{p.Text("map["), pr.expr(x.Key), p.Text("]"), pr.expr(x.Value)})
+	case *ast.ChanType:
+		dir := "chan "
+		switch x.Dir {
+		case ast.SEND:
+			dir = "chan<- "
+		case ast.RECV:
+			dir = "<-chan "
+		}
+		return p.Concat([]p.Doc{p.Text(dir), pr.expr(x.Value)})
+	}
+	if generic, lbrack, indices, rbrack, ok := indexList(x); ok {
+		return p.Concat([]p.Doc{
+			pr.expr(generic),
+			pr.inline(generic.End(), lbrack),
+			pr.list("[", exprNodes(indices), pr.elem, "]", lbrack, rbrack),
+		})
+	}
+	return p.Empty()
+}
+
+// joins reports whether the unary operators are scanned as another token
+// without a space between them.
+func joins(op token.Token, next token.Token) bool {
+	switch op {
+	case token.ADD, token.SUB:
+		return next == op
+	case token.AND:
+		return next == token.AND || next == token.XOR
+	}
+	return false
+}
+
+// member lays out a method or an embedded type of an interface.
+func (pr *printer) member(n ast.Node) p.Doc {
+	f := n.(*ast.Field)
+	if t, ok := f.Type.(*ast.FuncType); ok && len(f.Names) == 1 {
+		return p.Concat([]p.Doc{p.Text(f.Names[0].Name), pr.signature(t)})
+	}
+	return pr.field(0, f)
+}
+
+// chain lays out the operands of the binary operator. The operands of &&
+// and || are broken into lines after the operators when they don't fit,
+// and the operands are always broken after the comments which follow the
+// operators. The operators of higher precedence are not spaced when they
+// are mixed with lower ones, such as `a*b + c`, like gofmt.
+func (pr *printer) chain(x *ast.BinaryExpr) p.Doc {
+	breakable := (x.Op == token.LAND || x.Op == token.LOR) && !pr.isHard(x)
+	depth := pr.depth
+	operands := []ast.Expr{x.Y}
+	// blanks reports whether the operators before the operands are spaced.
+	blanks := []bool{x.Op.Precedence() < cutoff(x, depth)}
+	for {
+		y, ok := x.X.(*ast.BinaryExpr)
+		if !ok || y.Op != x.Op {
+			break
+		}
+		operands = append(operands, y.Y)
+		blanks = append(blanks, y.Op.Precedence() < cutoff(y, depth))
+		x = y
+	}
+	// the left operand of another precedence is deeper, like gofmt.
+	first := pr.at(depth+1, x.X)
+	if y, ok := x.X.(*ast.BinaryExpr); ok && y.Op.Precedence() == x.Op.Precedence() {
+		first = pr.expr(x.X)
+	}
+	operands = append(operands, x.X)
+	ds := []p.Doc{}
+	for i := len(operands) - 2; i >= 0; i-- {
+		switch {
+		case breakable:
+			ds = append(ds, p.Text(" "+x.Op.String()), p.Line())
+		case pr.hasComment(operands[i+1].End(), operands[i].Pos()):
+			ds = append(ds, p.Text(" "+x.Op.String()))
+			pr.lastEnd = operands[i+1].End()
+			ds = append(ds, pr.before(operands[i].Pos()), p.LineBreak())
+		case blanks[i]:
+			ds = append(ds, p.Text(" "+x.Op.String()+" "))
+		default:
+			ds = append(ds, p.Text(x.Op.String()))
+		}
+		ds = append(ds, pr.at(depth+1, operands[i]))
+	}
+	doc := p.Concat([]p.Doc{first, pr.nest(p.Concat(ds))})
+	if !breakable {
+		return doc
+	}
+	return p.Group(doc)
+}
+
+// cutoff is the precedence from which the binary operators in the
+// expression at the depth are not spaced, which is decided as gofmt does.
+func cutoff(x *ast.BinaryExpr, depth int) int {
+	has4, has5, maxProblem := walkBinary(x)
+	if maxProblem > 0 {
+		return maxProblem + 1
+	}
+	if has4 && has5 {
+		if depth == 1 {
+			return 5
+		}
+		return 4
+	}
+	if depth == 1 {
+		return 6
+	}
+	return 4
+}
+
+// walkBinary reports whether the binary expression has the operators of
+// the precedences 4 and 5 without parentheses, and the precedence whose
+// operators must be spaced from the unary operators of the operands, such
+// as `a / *b`.
+func walkBinary(x *ast.BinaryExpr) (has4 bool, has5 bool, maxProblem int) {
+	switch x.Op.Precedence() {
+	case 4:
+		has4 = true
+	case 5:
+		has5 = true
+	}
+	if l, ok := x.X.(*ast.BinaryExpr); ok && l.Op.Precedence() >= x.Op.Precedence() {
+		h4, h5, mp := walkBinary(l)
+		has4, has5 = has4 || h4, has5 || h5
+		if mp > maxProblem {
+			maxProblem = mp
+		}
+	}
+	switch r := x.Y.(type) {
+	case *ast.BinaryExpr:
+		if r.Op.Precedence() > x.Op.Precedence() {
+			h4, h5, mp := walkBinary(r)
+			has4, has5 = has4 || h4, has5 || h5
+			if mp > maxProblem {
+				maxProblem = mp
+			}
+		}
+	case *ast.StarExpr:
+		if x.Op == token.QUO {
+			// a/*b starts a comment.
+			maxProblem = 5
+		}
+	case *ast.UnaryExpr:
+		switch x.Op.String() + r.Op.String() {
+		case "/*", "&&", "&^":
+			maxProblem = 5
+		case "++", "--":
+			if maxProblem < 4 {
+				maxProblem = 4
+			}
+		}
+	}
+	return has4, has5, maxProblem
+}
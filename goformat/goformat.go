@@ -0,0 +1,194 @@
+/*
+Package goformat formats Go source code with prettier.
+
+Unlike gofmt, which never wraps lines, the argument lists of calls, the
+elements of composite literals and the parameters and the results of
+function signatures are broken into lines, one element on each line with
+a trailing comma, when they don't fit within the width:
+
+	result, err := client.Do(
+		ctx,
+		request,
+		retry.WithBackoff(time.Second),
+	)
+
+A call whose last argument is a function literal or a composite literal
+keeps the other arguments on the first line. Chains of && and || are broken
+after the operators. Comments are kept at their places by ast.CommentMap,
+and the blank lines between statements and declarations are kept.
+*/
+package goformat
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/scanner"
+	"go/token"
+	"sort"
+	"strings"
+
+	p "github.com/tanishiking/prettier"
+)
+
+// Options configures how Go source code is formatted.
+type Options struct {
+	// Indent is the width of a level of indentation, which is counted in
+	// the width of lines. Zero means 4.
+	Indent uint
+	// Spaces indents lines with Indent spaces instead of tabs.
+	Spaces bool
+}
+
+// Format parses the Go source file, and formats it within the width when
+// possible. The output is always a valid Go source file, and formatting
+// the output again doesn't change it.
+func Format(src []byte, width int, opts Options) ([]byte, error) {
+	if opts.Indent == 0 {
+		opts.Indent = 4
+	}
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "", src, parser.ParseComments)
+	if err != nil {
+		return nil, err
+	}
+	out := []byte(indent(p.Pretty(width, File(fset, file, opts)), opts) + "\n")
+	if _, err := parser.ParseFile(token.NewFileSet(), "", out, parser.ParseComments); err != nil {
+		return nil, fmt.Errorf("goformat: formatted source is invalid: %v", err)
+	}
+	return out, nil
+}
+
+// File converts the Go source file to a Doc. The lines of the Doc are
+// indented with spaces, which Format replaces with tabs.
+func File(fset *token.FileSet, file *ast.File, opts Options) p.Doc {
+	if opts.Indent == 0 {
+		opts.Indent = 4
+	}
+	comments := append([]*ast.CommentGroup{}, file.Comments...)
+	sort.Slice(comments, func(i, j int) bool {
+		return comments[i].Pos() < comments[j].Pos()
+	})
+	pr := &printer{
+		fset:     fset,
+		opts:     opts,
+		cmap:     ast.NewCommentMap(fset, file, comments),
+		comments: comments,
+		printed:  map[*ast.CommentGroup]bool{},
+		hard:     map[ast.Node]bool{},
+		depth:    1,
+	}
+	return pr.file(file)
+}
+
+type printer struct {
+	fset *token.FileSet
+	opts Options
+	cmap ast.CommentMap
+	// comments is the comment groups of the file in the order of their
+	// positions.
+	comments []*ast.CommentGroup
+	printed  map[*ast.CommentGroup]bool
+	// unprinted is the index of the first comment group which may not be
+	// laid out yet.
+	unprinted int
+	// hard is the nodes whose lines are always broken.
+	hard map[ast.Node]bool
+	// lastEnd is the end of the last node or comment laid out, which is
+	// used to keep blank lines.
+	lastEnd token.Pos
+	// depth is the depth of the expression being laid out, which decides
+	// the spaces around binary operators like gofmt.
+	depth int
+}
+
+func (pr *printer) line(pos token.Pos) int {
+	return pr.fset.Position(pos).Line
+}
+
+// at lays out the expression at the depth.
+func (pr *printer) at(depth int, x ast.Expr) p.Doc {
+	saved := pr.depth
+	pr.depth = depth
+	doc := pr.expr(x)
+	pr.depth = saved
+	return doc
+}
+
+func (pr *printer) nest(doc p.Doc) p.Doc {
+	return p.Nest(pr.opts.Indent, doc)
+}
+
+// raw is the text which may have newlines, such as a raw string literal.
+// Only the first line is counted in the width.
+func raw(s string) p.Doc {
+	if i := strings.IndexByte(s, '\n'); i >= 0 {
+		return p.TextWithLength(s, len([]rune(s[:i])))
+	}
+	return p.Text(s)
+}
+
+// indent replaces the spaces indenting the lines with tabs, and removes
+// the spaces of blank lines. The lines in raw string literals and comments
+// are kept as they are.
+func indent(src string, opts Options) string {
+	kept := multilineTokens(src)
+	var b strings.Builder
+	offset := 0
+	for i, line := range strings.Split(src, "\n") {
+		if i > 0 {
+			b.WriteByte('\n')
+		}
+		start := offset
+		offset += len(line) + 1
+		if i > 0 && kept(start) {
+			b.WriteString(line)
+			continue
+		}
+		trimmed := strings.TrimLeft(line, " ")
+		if trimmed == "" {
+			continue
+		}
+		n := uint(len(line) - len(trimmed))
+		if !opts.Spaces {
+			b.WriteString(strings.Repeat("\t", int(n/opts.Indent)))
+			n %= opts.Indent
+		}
+		b.WriteString(strings.Repeat(" ", int(n)))
+		b.WriteString(trimmed)
+	}
+	return b.String()
+}
+
+// multilineTokens returns a function which reports whether the offset is
+// in a raw string literal or a comment which spans lines.
+func multilineTokens(src string) func(int) bool {
+	type span struct{ start, end int }
+	spans := []span{}
+	fset := token.NewFileSet()
+	file := fset.AddFile("", -1, len(src))
+	var s scanner.Scanner
+	s.Init(file, []byte(src), nil, scanner.ScanComments)
+	for {
+		pos, tok, lit := s.Scan()
+		if tok == token.EOF {
+			break
+		}
+		if (tok == token.STRING || tok == token.COMMENT) && strings.Contains(lit, "\n") {
+			start := file.Offset(pos)
+			end := len(src)
+			if strings.HasPrefix(lit, "`") {
+				if i := strings.IndexByte(src[start+1:], '`'); i >= 0 {
+					end = start + i + 2
+				}
+			} else if i := strings.Index(src[start:], "*/"); i >= 0 {
+				end = start + i + 2
+			}
+			spans = append(spans, span{start, end})
+		}
+	}
+	return func(offset int) bool {
+		i := sort.Search(len(spans), func(i int) bool { return spans[i].end > offset })
+		return i < len(spans) && spans[i].start < offset
+	}
+}
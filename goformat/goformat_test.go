@@ -0,0 +1,340 @@
+package goformat
+
+import (
+	"fmt"
+	"go/parser"
+	"go/scanner"
+	"go/token"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	p "github.com/tanishiking/prettier"
+)
+
+func format(t *testing.T, src string, width int, opts Options) string {
+	t.Helper()
+	out, err := Format([]byte(src), width, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(out)
+}
+
+const client = `package sample
+
+func (c *Client) Do(ctx context.Context, request *Request, options ...Option) (*Response, error) {
+	if ctx == nil || request == nil || len(options) > maxOptions {
+		return nil, fmt.Errorf("invalid request: %v", request)
+	}
+	handlers := map[string]func(int) error{"a": nil, "b": nil}
+	sort.Slice(items, func(i, j int) bool { return items[i].priority < items[j].priority })
+	return c.transport.RoundTrip(ctx, request, retry.WithBackoff(time.Second), handlers)
+}
+`
+
+func TestFormat(t *testing.T) {
+	tests := []struct {
+		width    int
+		expected string
+	}{
+		{100, `package sample
+
+func (c *Client) Do(ctx context.Context, request *Request, options ...Option) (*Response, error) {
+	if ctx == nil || request == nil || len(options) > maxOptions {
+		return nil, fmt.Errorf("invalid request: %v", request)
+	}
+	handlers := map[string]func(int) error{"a": nil, "b": nil}
+	sort.Slice(items, func(i, j int) bool {
+		return items[i].priority < items[j].priority
+	})
+	return c.transport.RoundTrip(ctx, request, retry.WithBackoff(time.Second), handlers)
+}
+`},
+		{60, `package sample
+
+func (c *Client) Do(
+	ctx context.Context,
+	request *Request,
+	options ...Option,
+) (*Response, error) {
+	if ctx == nil ||
+		request == nil ||
+		len(options) > maxOptions {
+		return nil, fmt.Errorf(
+			"invalid request: %v",
+			request,
+		)
+	}
+	handlers := map[string]func(int) error{
+		"a": nil,
+		"b": nil,
+	}
+	sort.Slice(items, func(i, j int) bool {
+		return items[i].priority < items[j].priority
+	})
+	return c.transport.RoundTrip(
+		ctx,
+		request,
+		retry.WithBackoff(time.Second),
+		handlers,
+	)
+}
+`},
+	}
+	for _, tt := range tests {
+		if actual := format(t, client, tt.width, Options{}); actual != tt.expected {
+			t.Errorf("expected: %v, actual: %v", tt.expected, actual)
+		}
+	}
+}
+
+func TestFormatSpaces(t *testing.T) {
+	expected := `package sample
+
+func (c *Client) Do(
+  ctx context.Context,
+  request *Request,
+  options ...Option,
+) (*Response, error) {
+  if ctx == nil ||
+    request == nil ||
+    len(options) > maxOptions {
+    return nil, fmt.Errorf("invalid request: %v", request)
+  }
+  handlers := map[string]func(int) error{"a": nil, "b": nil}
+  sort.Slice(items, func(i, j int) bool {
+    return items[i].priority < items[j].priority
+  })
+  return c.transport.RoundTrip(
+    ctx,
+    request,
+    retry.WithBackoff(time.Second),
+    handlers,
+  )
+}
+`
+	if actual := format(t, client, 60, Options{Indent: 2, Spaces: true}); actual != expected {
+		t.Errorf("expected: %v, actual: %v", expected, actual)
+	}
+}
+
+func TestFormatComments(t *testing.T) {
+	src := `// Package sample is a sample.
+package sample
+// Sum adds the numbers.
+func Sum(numbers ...int) (total int) {
+	for _, n := range numbers { // each number
+		total += n
+	}
+
+	// the total
+	return
+}
+
+var table = []struct {
+	in, out string
+}{
+	{"a", "b"}, // first
+	// second
+	{"c", "d"},
+}
+
+var ok = len(table) > 0 && // not empty
+	table[0].in != ""
+`
+	expected := `// Package sample is a sample.
+package sample
+
+// Sum adds the numbers.
+func Sum(numbers ...int) (total int) {
+	for _, n := range numbers { // each number
+		total += n
+	}
+
+	// the total
+	return
+}
+
+var table = []struct {
+	in, out string
+}{
+	{"a", "b"}, // first
+	// second
+	{"c", "d"},
+}
+
+var ok = len(table) > 0 && // not empty
+	table[0].in != ""
+`
+	if actual := format(t, src, 20, Options{}); actual != expected {
+		t.Errorf("expected: %v, actual: %v", expected, actual)
+	}
+}
+
+func TestFormatLabels(t *testing.T) {
+	src := `package sample
+
+func f() {
+L: // the loop
+	// the comment
+	for {
+		switch {
+		case true:
+		M:
+			break L
+		}
+	}
+}
+`
+	// the labels are indented a level less like gofmt.
+	if actual := format(t, src, 80, Options{}); actual != src {
+		t.Errorf("expected: %v, actual: %v", src, actual)
+	}
+}
+
+func TestFormatOperators(t *testing.T) {
+	src := `package sample
+
+func f() {
+	if fails >= 4 + i >> 4 {
+		x := a * b + c
+		y, z := a + b, c * d
+		g(a + b, s[i + 1], s[a + b : c])
+		h((a + b) * c, a / *p)
+	}
+}
+`
+	// the operators are spaced by precedence like gofmt.
+	expected := `package sample
+
+func f() {
+	if fails >= 4+i>>4 {
+		x := a*b + c
+		y, z := a+b, c*d
+		g(a+b, s[i+1], s[a+b:c])
+		h((a+b)*c, a / *p)
+	}
+}
+`
+	if actual := format(t, src, 80, Options{}); actual != expected {
+		t.Errorf("expected: %v, actual: %v", expected, actual)
+	}
+}
+
+func TestFormatRaw(t *testing.T) {
+	src := "package sample\n\nvar usage = fmt.Sprintf(`usage:\n    sample [flags]`, name)\n"
+	expected := "package sample\n\nvar usage = fmt.Sprintf(\n\t`usage:\n    sample [flags]`,\n\tname,\n)\n"
+	if actual := format(t, src, 30, Options{}); actual != expected {
+		t.Errorf("expected: %v, actual: %v", expected, actual)
+	}
+}
+
+// tokens is the tokens of the source except for comments, semicolons and
+// trailing commas, which are laid out differently.
+func tokens(t *testing.T, src []byte) (string, []string) {
+	t.Helper()
+	fset := token.NewFileSet()
+	var s scanner.Scanner
+	s.Init(fset.AddFile("", -1, len(src)), src, nil, scanner.ScanComments)
+	toks := []string{}
+	comments := []string{}
+	for {
+		_, tok, lit := s.Scan()
+		switch {
+		case tok == token.EOF:
+			return strings.Join(toks, " "), comments
+		case tok == token.COMMENT:
+			comments = append(comments, lit)
+		case tok == token.SEMICOLON:
+		case tok.IsLiteral():
+			toks = append(toks, lit)
+		case len(toks) > 0 && toks[len(toks)-1] == "," && (tok == token.RPAREN || tok == token.RBRACK || tok == token.RBRACE):
+			toks[len(toks)-1] = tok.String()
+		default:
+			toks = append(toks, tok.String())
+		}
+	}
+}
+
+func TestFormatFiles(t *testing.T) {
+	files, err := filepath.Glob("../*/*.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	root, err := filepath.Glob("../*.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range append(files, root...) {
+		src, err := ioutil.ReadFile(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		toks, comments := tokens(t, src)
+		for _, width := range []int{40, 80, 120} {
+			once := format(t, string(src), width, Options{})
+			if actual, actualComments := tokens(t, []byte(once)); actual != toks {
+				t.Errorf("%s (%d): the tokens are changed", name, width)
+			} else if strings.Join(actualComments, "\n") != strings.Join(comments, "\n") {
+				t.Errorf("%s (%d): the comments are changed", name, width)
+			}
+			if twice := format(t, once, width, Options{}); twice != once {
+				t.Errorf("%s (%d): expected: %v, actual: %v", name, width, once, twice)
+			}
+		}
+	}
+}
+
+func TestFormatError(t *testing.T) {
+	if _, err := Format([]byte("package p\nfunc f( {}"), 80, Options{}); err == nil {
+		t.Errorf("expected an error")
+	}
+}
+
+func ExampleFormat() {
+	src := `package main
+
+func main() {
+	fmt.Println("hello", strings.Repeat("world", 3), os.Args[1:])
+}
+`
+	out, err := Format([]byte(src), 40, Options{})
+	if err != nil {
+		panic(err)
+	}
+	os.Stdout.Write(out)
+	// Output:
+	// package main
+	//
+	// func main() {
+	// 	fmt.Println(
+	// 		"hello",
+	// 		strings.Repeat("world", 3),
+	// 		os.Args[1:],
+	// 	)
+	// }
+}
+
+func ExampleFile() {
+	src := "package main\n\nvar primes = []int{2, 3, 5, 7, 11, 13}\n"
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "", src, parser.ParseComments)
+	if err != nil {
+		panic(err)
+	}
+	fmt.Println(p.Pretty(20, File(fset, file, Options{Indent: 2})))
+	// Output:
+	// package main
+	//
+	// var primes = []int{
+	//   2,
+	//   3,
+	//   5,
+	//   7,
+	//   11,
+	//   13,
+	// }
+}
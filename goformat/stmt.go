@@ -0,0 +1,200 @@
+package goformat
+
+import (
+	"go/ast"
+	"go/token"
+
+	p "github.com/tanishiking/prettier"
+)
+
+// block lays out the statements in the braces, each on its own line.
+func (pr *printer) block(b *ast.BlockStmt) p.Doc {
+	// the statements are at the top of the depth of expressions, even in
+	// function literals.
+	saved := pr.depth
+	pr.depth = 1
+	doc := pr.braces(b.Lbrace, stmtNodes(b.List), pr.stmt, b.Rbrace, true)
+	pr.depth = saved
+	return doc
+}
+
+// braces lays out the nodes in the braces, each on its own line. The
+// nodes are indented if nested is true.
+func (pr *printer) braces(lbrace token.Pos, nodes []ast.Node, layout func(ast.Node) p.Doc, rbrace token.Pos, nested bool) p.Doc {
+	if len(nodes) == 0 && !pr.hasComment(lbrace, rbrace) {
+		return p.Text("{}")
+	}
+	pr.lastEnd = lbrace
+	body := pr.lines(nodes, rbrace, layout, nested)
+	comments := pr.before(rbrace)
+	if nested {
+		comments = pr.nest(comments)
+	}
+	return p.Concat([]p.Doc{p.Text("{"), body, comments, p.LineBreak(), p.Text("}")})
+}
+
+// stmtNodes is the statements except for the empty statements.
+func stmtNodes(stmts []ast.Stmt) []ast.Node {
+	nodes := []ast.Node{}
+	for _, s := range stmts {
+		if _, ok := s.(*ast.EmptyStmt); !ok {
+			nodes = append(nodes, s)
+		}
+	}
+	return nodes
+}
+
+func (pr *printer) stmt(n ast.Node) p.Doc {
+	switch s := n.(type) {
+	case *ast.EmptyStmt:
+		return p.Text(";")
+	case *ast.DeclStmt:
+		return pr.decl(s.Decl)
+	case *ast.LabeledStmt:
+		doc := p.Text(s.Label.Name + ":")
+		if e, ok := s.Stmt.(*ast.EmptyStmt); ok && e.Implicit {
+			// the label is at the end of the block.
+			return doc
+		}
+		// the comments between the label and the statement.
+		pr.lastEnd = s.Colon + 1
+		return p.Concat([]p.Doc{doc, pr.before(s.Stmt.Pos()), p.LineBreak(), pr.stmt(s.Stmt)})
+	case *ast.ExprStmt:
+		return pr.expr(s.X)
+	case *ast.SendStmt:
+		return p.Concat([]p.Doc{pr.expr(s.Chan), p.Text(" <- "), pr.expr(s.Value)})
+	case *ast.IncDecStmt:
+		return p.Concat([]p.Doc{pr.expr(s.X), p.Text(s.Tok.String())})
+	case *ast.AssignStmt:
+		saved := pr.depth
+		if len(s.Lhs) > 1 && len(s.Rhs) > 1 {
+			// the expressions of parallel assignments are spaced less.
+			pr.depth++
+		}
+		doc := p.Concat([]p.Doc{
+			pr.exprs(s.Lhs),
+			p.Text(" " + s.Tok.String() + " "),
+			pr.exprs(s.Rhs),
+		})
+		pr.depth = saved
+		return doc
+	case *ast.GoStmt:
+		return p.Concat([]p.Doc{p.Text("go "), pr.expr(s.Call)})
+	case *ast.DeferStmt:
+		return p.Concat([]p.Doc{p.Text("defer "), pr.expr(s.Call)})
+	case *ast.ReturnStmt:
+		if len(s.Results) == 0 {
+			return p.Text("return")
+		}
+		return p.Concat([]p.Doc{p.Text("return "), pr.exprs(s.Results)})
+	case *ast.BranchStmt:
+		if s.Label != nil {
+			return p.Text(s.Tok.String() + " " + s.Label.Name)
+		}
+		return p.Text(s.Tok.String())
+	case *ast.BlockStmt:
+		return pr.block(s)
+	case *ast.IfStmt:
+		ds := []p.Doc{p.Text("if "), pr.header(s.Init, s.Cond), p.Text(" "), pr.block(s.Body)}
+		if s.Else != nil {
+			ds = append(ds, p.Text(" else "), pr.stmt(s.Else))
+		}
+		return p.Concat(ds)
+	case *ast.CaseClause:
+		doc := p.Text("default:")
+		if s.List != nil {
+			doc = p.Concat([]p.Doc{p.Text("case "), pr.exprs(s.List), p.Text(":")})
+		}
+		return p.Concat([]p.Doc{doc, pr.clause(s.Colon, s.Body)})
+	case *ast.SwitchStmt:
+		ds := []p.Doc{p.Text("switch ")}
+		if s.Init != nil {
+			ds = append(ds, pr.stmt(s.Init), p.Text(";"))
+			if s.Tag != nil {
+				ds = append(ds, p.Text(" "))
+			}
+		}
+		if s.Tag != nil {
+			ds = append(ds, pr.expr(s.Tag))
+		}
+		if s.Init != nil || s.Tag != nil {
+			ds = append(ds, p.Text(" "))
+		}
+		ds = append(ds, pr.clauses(s.Body))
+		return p.Concat(ds)
+	case *ast.TypeSwitchStmt:
+		return p.Concat([]p.Doc{
+			p.Text("switch "),
+			pr.header(s.Init, s.Assign),
+			p.Text(" "),
+			pr.clauses(s.Body),
+		})
+	case *ast.CommClause:
+		doc := p.Text("default:")
+		if s.Comm != nil {
+			doc = p.Concat([]p.Doc{p.Text("case "), pr.stmt(s.Comm), p.Text(":")})
+		}
+		return p.Concat([]p.Doc{doc, pr.clause(s.Colon, s.Body)})
+	case *ast.SelectStmt:
+		return p.Concat([]p.Doc{p.Text("select "), pr.clauses(s.Body)})
+	case *ast.ForStmt:
+		ds := []p.Doc{p.Text("for ")}
+		if s.Init != nil || s.Post != nil {
+			ds = append(ds, pr.header(s.Init, nil), p.Text("; "))
+			if s.Cond != nil {
+				ds = append(ds, pr.expr(s.Cond))
+			}
+			ds = append(ds, p.Text("; "))
+			if s.Post != nil {
+				ds = append(ds, pr.stmt(s.Post), p.Text(" "))
+			}
+		} else if s.Cond != nil {
+			ds = append(ds, pr.expr(s.Cond), p.Text(" "))
+		}
+		ds = append(ds, pr.block(s.Body))
+		return p.Concat(ds)
+	case *ast.RangeStmt:
+		ds := []p.Doc{p.Text("for ")}
+		if s.Key != nil {
+			ds = append(ds, pr.expr(s.Key))
+			if s.Value != nil {
+				ds = append(ds, p.Text(", "), pr.expr(s.Value))
+			}
+			ds = append(ds, p.Text(" "+s.Tok.String()+" "))
+		}
+		ds = append(ds, p.Text("range "), pr.expr(s.X), p.Text(" "), pr.block(s.Body))
+		return p.Concat(ds)
+	}
+	return p.Empty()
+}
+
+// header lays out the simple statement and the expression or the
+// statement of an if, a switch or a for statement.
+func (pr *printer) header(init ast.Stmt, n ast.Node) p.Doc {
+	ds := []p.Doc{}
+	if init != nil {
+		ds = append(ds, pr.stmt(init))
+		if n != nil {
+			ds = append(ds, p.Text("; "))
+		}
+	}
+	switch n := n.(type) {
+	case ast.Stmt:
+		ds = append(ds, pr.stmt(n))
+	case ast.Expr:
+		ds = append(ds, pr.expr(n))
+	}
+	return p.Concat(ds)
+}
+
+// clauses lays out the case clauses of a switch or a select statement,
+// which are not indented.
+func (pr *printer) clauses(body *ast.BlockStmt) p.Doc {
+	return pr.braces(body.Lbrace, stmtNodes(body.List), pr.stmt, body.Rbrace, false)
+}
+
+// clause lays out the statements of a case clause.
+func (pr *printer) clause(colon token.Pos, body []ast.Stmt) p.Doc {
+	pr.lastEnd = colon
+	return pr.lines(stmtNodes(body), token.Pos(pr.fset.Base()), pr.stmt, true)
+}
@@ -0,0 +1,21 @@
+//go:build !go1.18
+// +build !go1.18
+
+package goformat
+
+import (
+	"go/ast"
+	"go/token"
+)
+
+// typeParams is the type parameters of the function type or the type
+// spec, which are not supported before Go 1.18.
+func typeParams(n ast.Node) *ast.FieldList {
+	return nil
+}
+
+// indexList splits the instantiation of a generic type or function with
+// multiple type arguments, which are not supported before Go 1.18.
+func indexList(x ast.Expr) (ast.Expr, token.Pos, []ast.Expr, token.Pos, bool) {
+	return nil, token.NoPos, nil, token.NoPos, false
+}
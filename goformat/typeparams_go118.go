@@ -0,0 +1,30 @@
+//go:build go1.18
+// +build go1.18
+
+package goformat
+
+import (
+	"go/ast"
+	"go/token"
+)
+
+// typeParams is the type parameters of the function type or the type
+// spec.
+func typeParams(n ast.Node) *ast.FieldList {
+	switch n := n.(type) {
+	case *ast.FuncType:
+		return n.TypeParams
+	case *ast.TypeSpec:
+		return n.TypeParams
+	}
+	return nil
+}
+
+// indexList splits the instantiation of a generic type or function with
+// multiple type arguments.
+func indexList(x ast.Expr) (ast.Expr, token.Pos, []ast.Expr, token.Pos, bool) {
+	if x, ok := x.(*ast.IndexListExpr); ok {
+		return x.X, x.Lbrack, x.Indices, x.Rbrack, true
+	}
+	return nil, token.NoPos, nil, token.NoPos, false
+}
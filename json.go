@@ -0,0 +1,388 @@
+package prettier
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"unicode/utf8"
+)
+
+// jsonDoc is the JSON object of a Doc node.
+type jsonDoc struct {
+	Type     string          `json:"type"`
+	Text     string          `json:"text,omitempty"`
+	Length   *int            `json:"length,omitempty"`
+	Soft     bool            `json:"soft,omitempty"`
+	Indent   uint            `json:"indent,omitempty"`
+	Flat     json.RawMessage `json:"flat,omitempty"`
+	Break    json.RawMessage `json:"break,omitempty"`
+	Sep      json.RawMessage `json:"separator,omitempty"`
+	Parts    json.RawMessage `json:"parts,omitempty"`
+	Style    *Style          `json:"style,omitempty"`
+	Source   *SourcePos      `json:"source,omitempty"`
+	Value    json.RawMessage `json:"value,omitempty"`
+	Contents json.RawMessage `json:"contents,omitempty"`
+}
+
+// MarshalJSON implements json.Marshaler.
+func (e *empty) MarshalJSON() ([]byte, error) {
+	return marshalDoc(e)
+}
+
+// MarshalJSON implements json.Marshaler.
+func (t *text) MarshalJSON() ([]byte, error) {
+	return marshalDoc(t)
+}
+
+// MarshalJSON implements json.Marshaler.
+func (l *line) MarshalJSON() ([]byte, error) {
+	return marshalDoc(l)
+}
+
+// MarshalJSON implements json.Marshaler.
+func (c *concat) MarshalJSON() ([]byte, error) {
+	return marshalDoc(c)
+}
+
+// MarshalJSON implements json.Marshaler.
+func (n *nest) MarshalJSON() ([]byte, error) {
+	return marshalDoc(n)
+}
+
+// MarshalJSON implements json.Marshaler.
+func (a *align) MarshalJSON() ([]byte, error) {
+	return marshalDoc(a)
+}
+
+// MarshalJSON implements json.Marshaler.
+func (g *group) MarshalJSON() ([]byte, error) {
+	return marshalDoc(g)
+}
+
+// MarshalJSON implements json.Marshaler.
+func (i *ifBreak) MarshalJSON() ([]byte, error) {
+	return marshalDoc(i)
+}
+
+// MarshalJSON implements json.Marshaler.
+func (f *fill) MarshalJSON() ([]byte, error) {
+	return marshalDoc(f)
+}
+
+// MarshalJSON implements json.Marshaler.
+func (u *union) MarshalJSON() ([]byte, error) {
+	return marshalDoc(u)
+}
+
+// MarshalJSON implements json.Marshaler.
+func (l *lazyDoc) MarshalJSON() ([]byte, error) {
+	return marshalDoc(l)
+}
+
+// MarshalJSON implements json.Marshaler.
+func (a *annotation) MarshalJSON() ([]byte, error) {
+	return marshalDoc(a)
+}
+
+// MarshalJSON implements json.Marshaler.
+func (c *cursor) MarshalJSON() ([]byte, error) {
+	return marshalDoc(c)
+}
+
+func marshalDoc(doc Doc) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := writeJSON(&buf, doc); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func writeJSON(buf *bytes.Buffer, doc Doc) error {
+	switch v := doc.(type) {
+	case *empty:
+		buf.WriteString("[]")
+		return nil
+	case *text:
+		if v.length == utf8.RuneCountInString(v.str) {
+			return writeJSONValue(buf, v.str)
+		}
+		length := v.length
+		return writeJSONValue(buf, &jsonDoc{Type: "text", Text: v.str, Length: &length})
+	case *line:
+		return writeJSONValue(buf, &jsonDoc{Type: "line", Soft: !v.flattenToSpace})
+	case *concat:
+		buf.WriteByte('[')
+		// right-associated concatenations are written as a single array,
+		// which is read back as the same concatenation by Concat.
+		var d Doc = v
+		for {
+			c, ok := d.(*concat)
+			if !ok {
+				break
+			}
+			if err := writeJSON(buf, c.a); err != nil {
+				return err
+			}
+			buf.WriteByte(',')
+			d = c.b
+		}
+		if err := writeJSON(buf, d); err != nil {
+			return err
+		}
+		buf.WriteByte(']')
+		return nil
+	case *nest:
+		fmt.Fprintf(buf, `{"type":"nest","indent":%d,"contents":`, v.indent)
+		if err := writeJSON(buf, v.doc); err != nil {
+			return err
+		}
+		buf.WriteByte('}')
+		return nil
+	case *align:
+		buf.WriteString(`{"type":"align","contents":`)
+		if err := writeJSON(buf, v.doc); err != nil {
+			return err
+		}
+		buf.WriteByte('}')
+		return nil
+	case *group:
+		buf.WriteString(`{"type":"group","contents":`)
+		if err := writeJSON(buf, v.doc); err != nil {
+			return err
+		}
+		buf.WriteByte('}')
+		return nil
+	case *ifBreak:
+		buf.WriteString(`{"type":"if-break","break":`)
+		if err := writeJSON(buf, v.broken); err != nil {
+			return err
+		}
+		buf.WriteString(`,"flat":`)
+		if err := writeJSON(buf, v.flat); err != nil {
+			return err
+		}
+		buf.WriteByte('}')
+		return nil
+	case *fill:
+		buf.WriteString(`{"type":"fill","separator":`)
+		if err := writeJSON(buf, v.sep); err != nil {
+			return err
+		}
+		buf.WriteString(`,"parts":[`)
+		for i, part := range v.parts {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			if err := writeJSON(buf, part); err != nil {
+				return err
+			}
+		}
+		buf.WriteString(`]}`)
+		return nil
+	case *union:
+		buf.WriteString(`{"type":"union","flat":`)
+		if err := writeJSON(buf, v.a); err != nil {
+			return err
+		}
+		buf.WriteString(`,"break":`)
+		if err := writeJSON(buf, v.b); err != nil {
+			return err
+		}
+		buf.WriteByte('}')
+		return nil
+	case *lazyDoc:
+		return writeJSON(buf, v.Evaluated())
+	case *annotation:
+		buf.WriteString(`{"type":"annotation",`)
+		var err error
+		switch value := v.value.(type) {
+		case Style:
+			buf.WriteString(`"style":`)
+			err = writeJSONValue(buf, value)
+		case SourcePos:
+			buf.WriteString(`"source":`)
+			err = writeJSONValue(buf, value)
+		default:
+			buf.WriteString(`"value":`)
+			err = writeJSONValue(buf, value)
+		}
+		if err != nil {
+			return err
+		}
+		buf.WriteString(`,"contents":`)
+		if err := writeJSON(buf, v.doc); err != nil {
+			return err
+		}
+		buf.WriteByte('}')
+		return nil
+	case *cursor:
+		return writeJSONValue(buf, &jsonDoc{Type: "cursor"})
+	default:
+		return fmt.Errorf("prettier: can't encode %v", doc)
+	}
+}
+
+func writeJSONValue(buf *bytes.Buffer, v interface{}) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	buf.Write(b)
+	return nil
+}
+
+// UnmarshalDoc decodes a Doc encoded by json.Marshal.
+//
+// Documents are encoded to JSON in the spirit of Prettier's doc JSON:
+//
+//   - Empty is encoded as an empty array `[]`.
+//   - Text is encoded as a string, or as
+//     `{"type": "text", "text": "...", "length": n}` if its length is not
+//     the number of runes in the string (see TextWithLength).
+//   - Concat is encoded as an array of its parts.
+//   - Line and LineBreak are encoded as `{"type": "line"}` and
+//     `{"type": "line", "soft": true}`.
+//   - Nest is encoded as `{"type": "nest", "indent": n, "contents": doc}`.
+//   - Align is encoded as `{"type": "align", "contents": doc}`.
+//   - Group is encoded as `{"type": "group", "contents": doc}`, and unions
+//     of alternative layouts are encoded as
+//     `{"type": "union", "flat": doc, "break": doc}`.
+//   - IfBreak is encoded as `{"type": "if-break", "break": doc, "flat": doc}`.
+//   - Fill is encoded as `{"type": "fill", "separator": doc, "parts": [doc]}`.
+//   - Annotate is encoded as `{"type": "annotation", "contents": doc}` with
+//     either "style" (for Style), "source" (for SourcePos) or "value"
+//     (for any other value, which must be encodable by encoding/json).
+//   - Cursor is encoded as `{"type": "cursor"}`.
+//
+// Lazy documents are evaluated and encoded as the evaluated documents.
+// Annotation values other than Style and SourcePos are decoded
+// as encoding/json decodes them into an interface{} value.
+func UnmarshalDoc(data []byte) (Doc, error) {
+	data = bytes.TrimSpace(data)
+	if len(data) == 0 {
+		return nil, fmt.Errorf("prettier: unexpected end of JSON input")
+	}
+	switch data[0] {
+	case '"':
+		var s string
+		if err := json.Unmarshal(data, &s); err != nil {
+			return nil, err
+		}
+		return Text(s), nil
+	case '[':
+		var raws []json.RawMessage
+		if err := json.Unmarshal(data, &raws); err != nil {
+			return nil, err
+		}
+		parts := []Doc{}
+		for _, raw := range raws {
+			part, err := UnmarshalDoc(raw)
+			if err != nil {
+				return nil, err
+			}
+			parts = append(parts, part)
+		}
+		return Concat(parts), nil
+	case '{':
+		var j jsonDoc
+		if err := json.Unmarshal(data, &j); err != nil {
+			return nil, err
+		}
+		return unmarshalObject(&j)
+	default:
+		return nil, fmt.Errorf("prettier: unexpected JSON for a doc: %s", data)
+	}
+}
+
+func unmarshalObject(j *jsonDoc) (Doc, error) {
+	switch j.Type {
+	case "text":
+		if j.Length == nil {
+			return Text(j.Text), nil
+		}
+		return TextWithLength(j.Text, *j.Length), nil
+	case "line":
+		if j.Soft {
+			return LineBreak(), nil
+		}
+		return Line(), nil
+	case "nest":
+		contents, err := UnmarshalDoc(j.Contents)
+		if err != nil {
+			return nil, err
+		}
+		return Nest(j.Indent, contents), nil
+	case "align":
+		contents, err := UnmarshalDoc(j.Contents)
+		if err != nil {
+			return nil, err
+		}
+		return Align(contents), nil
+	case "group":
+		contents, err := UnmarshalDoc(j.Contents)
+		if err != nil {
+			return nil, err
+		}
+		return Group(contents), nil
+	case "if-break":
+		broken, err := UnmarshalDoc(j.Break)
+		if err != nil {
+			return nil, err
+		}
+		flat, err := UnmarshalDoc(j.Flat)
+		if err != nil {
+			return nil, err
+		}
+		return IfBreak(broken, flat), nil
+	case "fill":
+		sep, err := UnmarshalDoc(j.Sep)
+		if err != nil {
+			return nil, err
+		}
+		var raws []json.RawMessage
+		if err := json.Unmarshal(j.Parts, &raws); err != nil {
+			return nil, err
+		}
+		parts := []Doc{}
+		for _, raw := range raws {
+			part, err := UnmarshalDoc(raw)
+			if err != nil {
+				return nil, err
+			}
+			parts = append(parts, part)
+		}
+		return Fill(sep, parts), nil
+	case "union":
+		a, err := UnmarshalDoc(j.Flat)
+		if err != nil {
+			return nil, err
+		}
+		b, err := UnmarshalDoc(j.Break)
+		if err != nil {
+			return nil, err
+		}
+		return &union{a: a, b: b}, nil
+	case "annotation":
+		contents, err := UnmarshalDoc(j.Contents)
+		if err != nil {
+			return nil, err
+		}
+		if j.Style != nil {
+			return Annotate(*j.Style, contents), nil
+		}
+		if j.Source != nil {
+			return Annotate(*j.Source, contents), nil
+		}
+		var value interface{}
+		if len(j.Value) > 0 {
+			if err := json.Unmarshal(j.Value, &value); err != nil {
+				return nil, err
+			}
+		}
+		return Annotate(value, contents), nil
+	case "cursor":
+		return Cursor(), nil
+	default:
+		return nil, fmt.Errorf("prettier: unknown doc type %q", j.Type)
+	}
+}
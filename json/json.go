@@ -0,0 +1,203 @@
+/*
+Package json lays out JSON values as documents of prettier,
+so that they are rendered within the width when possible.
+*/
+package json
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	p "github.com/tanishiking/prettier"
+)
+
+// Options configures how JSON values are laid out.
+type Options struct {
+	// Indent is the indentation of the elements of arrays and objects.
+	// Zero means 2.
+	Indent uint
+	// SortKeys sorts the members of objects by their keys. Members with
+	// the same key are kept in the order of the input.
+	SortKeys bool
+	// TrailingCommas writes a comma after the last element of arrays and
+	// objects which are broken into lines. Note that the output is not
+	// valid JSON, but is accepted by JSON5 and JavaScript.
+	TrailingCommas bool
+	// Compact lays out arrays and objects on one line if they fit within
+	// the width. Otherwise, every non-empty array and object is broken
+	// into lines, as json.MarshalIndent does.
+	Compact bool
+	// PreserveNumbers writes numbers exactly as they are written in the
+	// input. Otherwise, numbers are written as encoding/json encodes
+	// float64 values, e.g. 1.50 is written as 1.5. Numbers which are out
+	// of the range of float64, such as 1e400, are always written as they
+	// are.
+	PreserveNumbers bool
+}
+
+// Doc converts the JSON value to a Doc. data is typically
+// a json.RawMessage.
+func Doc(data []byte, opts Options) (p.Doc, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	doc, err := Decode(dec, opts)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return nil, fmt.Errorf("prettier/json: unexpected data after the top-level value")
+	}
+	return doc, nil
+}
+
+// Decode reads the next JSON value from the tokens of dec, and converts
+// it to a Doc. The value is not decoded into Go values, so large inputs
+// can be read from a stream; call Decode while dec.More() reports true
+// to read a stream of values.
+//
+// Decode calls dec.UseNumber so that numbers can be preserved.
+func Decode(dec *json.Decoder, opts Options) (p.Doc, error) {
+	if opts.Indent == 0 {
+		opts.Indent = 2
+	}
+	dec.UseNumber()
+	d := &decoder{dec: dec, opts: opts}
+	return d.value()
+}
+
+type decoder struct {
+	dec  *json.Decoder
+	opts Options
+}
+
+type member struct {
+	key string
+	doc p.Doc
+}
+
+func (d *decoder) value() (p.Doc, error) {
+	tok, err := d.dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	switch v := tok.(type) {
+	case json.Delim:
+		if v == '[' {
+			return d.array()
+		}
+		if v == '{' {
+			return d.object()
+		}
+		return nil, fmt.Errorf("prettier/json: unexpected %v", v)
+	case string:
+		return p.Text(quote(v)), nil
+	case json.Number:
+		return d.number(v)
+	case bool:
+		if v {
+			return p.Text("true"), nil
+		}
+		return p.Text("false"), nil
+	case nil:
+		return p.Text("null"), nil
+	default:
+		return nil, fmt.Errorf("prettier/json: unexpected token %v", tok)
+	}
+}
+
+func (d *decoder) array() (p.Doc, error) {
+	items := []p.Doc{}
+	for d.dec.More() {
+		item, err := d.value()
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	// the closing bracket.
+	if _, err := d.dec.Token(); err != nil {
+		return nil, err
+	}
+	return d.container("[", "]", items), nil
+}
+
+func (d *decoder) object() (p.Doc, error) {
+	members := []member{}
+	for d.dec.More() {
+		tok, err := d.dec.Token()
+		if err != nil {
+			return nil, err
+		}
+		key, ok := tok.(string)
+		if !ok {
+			return nil, fmt.Errorf("prettier/json: unexpected %v for a key", tok)
+		}
+		value, err := d.value()
+		if err != nil {
+			return nil, err
+		}
+		members = append(members, member{key: key, doc: value})
+	}
+	// the closing brace.
+	if _, err := d.dec.Token(); err != nil {
+		return nil, err
+	}
+	if d.opts.SortKeys {
+		sort.SliceStable(members, func(i, j int) bool {
+			return members[i].key < members[j].key
+		})
+	}
+	items := make([]p.Doc, len(members))
+	for i, m := range members {
+		items[i] = p.Concat([]p.Doc{p.Text(quote(m.key)), p.Text(": "), m.doc})
+	}
+	return d.container("{", "}", items), nil
+}
+
+func (d *decoder) container(left string, right string, items []p.Doc) p.Doc {
+	if len(items) == 0 {
+		return p.Text(left + right)
+	}
+	body := p.Intercalate(p.Concat([]p.Doc{p.Text(","), p.Line()}), items)
+	if d.opts.TrailingCommas {
+		body = p.Concat([]p.Doc{body, p.IfBreak(p.Text(","), p.Empty())})
+	}
+	if d.opts.Compact {
+		return p.TightBracketBy(p.Text(left), p.Text(right), body, d.opts.Indent)
+	}
+	// lines out of groups are always broken.
+	return p.Concat([]p.Doc{
+		p.Text(left),
+		p.Nest(d.opts.Indent, p.Concat([]p.Doc{p.LineBreak(), body})),
+		p.LineBreak(),
+		p.Text(right),
+	})
+}
+
+func (d *decoder) number(n json.Number) (p.Doc, error) {
+	if d.opts.PreserveNumbers {
+		return p.Text(n.String()), nil
+	}
+	f, err := n.Float64()
+	if err != nil {
+		return p.Text(n.String()), nil
+	}
+	b, err := json.Marshal(f)
+	if err != nil {
+		return p.Text(n.String()), nil
+	}
+	return p.Text(string(b)), nil
+}
+
+// quote encodes the string as a JSON string without escaping HTML.
+func quote(s string) string {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	// encoding a string never fails.
+	_ = enc.Encode(s)
+	return strings.TrimSuffix(buf.String(), "\n")
+}
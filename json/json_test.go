@@ -0,0 +1,140 @@
+package json
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	p "github.com/tanishiking/prettier"
+)
+
+const input = `{"name": "prettier", "tags": ["go", "pretty printer"], "stars": 1.50, "meta": {}, "list": []}`
+
+func pretty(t *testing.T, width int, data string, opts Options) string {
+	t.Helper()
+	doc, err := Doc([]byte(data), opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return p.Pretty(width, doc)
+}
+
+func TestDoc(t *testing.T) {
+	expected := `{
+  "name": "prettier",
+  "tags": [
+    "go",
+    "pretty printer"
+  ],
+  "stars": 1.5,
+  "meta": {},
+  "list": []
+}`
+	if actual := pretty(t, 80, input, Options{}); actual != expected {
+		t.Errorf("expected: %v, actual: %v", expected, actual)
+	}
+	// the same as json.Indent if numbers are preserved.
+	var indented bytes.Buffer
+	if err := json.Indent(&indented, []byte(input), "", "  "); err != nil {
+		t.Fatal(err)
+	}
+	if actual := pretty(t, 80, input, Options{PreserveNumbers: true}); actual != indented.String() {
+		t.Errorf("expected: %v, actual: %v", indented.String(), actual)
+	}
+	if actual := pretty(t, 80, `"<&>é"`, Options{}); actual != `"<&>é"` {
+		t.Errorf("expected: %v, actual: %v", `"<&>é"`, actual)
+	}
+}
+
+func TestDocCompact(t *testing.T) {
+	opts := Options{Compact: true}
+	expected := `{"name": "prettier", "tags": ["go", "pretty printer"], "stars": 1.5, "meta": {}, "list": []}`
+	if actual := pretty(t, 100, input, opts); actual != expected {
+		t.Errorf("expected: %v, actual: %v", expected, actual)
+	}
+	expected = `{
+  "name": "prettier",
+  "tags": ["go", "pretty printer"],
+  "stars": 1.5,
+  "meta": {},
+  "list": []
+}`
+	if actual := pretty(t, 40, input, opts); actual != expected {
+		t.Errorf("expected: %v, actual: %v", expected, actual)
+	}
+}
+
+func TestDocOptions(t *testing.T) {
+	opts := Options{
+		Indent:          4,
+		SortKeys:        true,
+		TrailingCommas:  true,
+		Compact:         true,
+		PreserveNumbers: true,
+	}
+	expected := `{
+    "list": [],
+    "meta": {},
+    "name": "prettier",
+    "stars": 1.50,
+    "tags": ["go", "pretty printer"],
+}`
+	if actual := pretty(t, 40, input, opts); actual != expected {
+		t.Errorf("expected: %v, actual: %v", expected, actual)
+	}
+	// no trailing commas on one line.
+	if actual := pretty(t, 40, `[1, 2]`, opts); actual != "[1, 2]" {
+		t.Errorf("expected: %v, actual: %v", "[1, 2]", actual)
+	}
+}
+
+func TestDocNumbers(t *testing.T) {
+	data := `[1.0, 1e3, -0.5e-10, 12345678901234567890, 1e400, -1E+400]`
+	expected := `[1, 1000, -5e-11, 12345678901234567000, 1e400, -1E+400]`
+	if actual := pretty(t, 80, data, Options{Compact: true}); actual != expected {
+		t.Errorf("expected: %v, actual: %v", expected, actual)
+	}
+	if actual := pretty(t, 80, data, Options{Compact: true, PreserveNumbers: true}); actual != data {
+		t.Errorf("expected: %v, actual: %v", data, actual)
+	}
+}
+
+func TestDocError(t *testing.T) {
+	for _, data := range []string{``, `[1, 2`, `{"a" 1}`, `[1] [2]`} {
+		if _, err := Doc([]byte(data), Options{}); err == nil {
+			t.Errorf("%q should be an error", data)
+		}
+	}
+}
+
+func TestDecodeStream(t *testing.T) {
+	dec := json.NewDecoder(strings.NewReader(`{"a": true} [null] "b"`))
+	actual := []string{}
+	for dec.More() {
+		doc, err := Decode(dec, Options{Compact: true})
+		if err != nil {
+			t.Fatal(err)
+		}
+		actual = append(actual, p.Pretty(80, doc))
+	}
+	expected := `{"a": true}|[null]|"b"`
+	if strings.Join(actual, "|") != expected {
+		t.Errorf("expected: %v, actual: %v", expected, strings.Join(actual, "|"))
+	}
+}
+
+func ExampleDoc() {
+	data := json.RawMessage(`{"name": "prettier", "tags": ["go", "pretty printer"]}`)
+	doc, err := Doc(data, Options{Compact: true})
+	if err != nil {
+		panic(err)
+	}
+	fmt.Println(p.Pretty(40, doc))
+	// Output:
+	// {
+	//   "name": "prettier",
+	//   "tags": ["go", "pretty printer"]
+	// }
+}
@@ -0,0 +1,116 @@
+package prettier
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestMarshalJSON(t *testing.T) {
+	doc := Concat([]Doc{
+		Text("a"),
+		Nest(uint(2), Group(Concat([]Doc{Line(), TextWithLength("b", 2)}))),
+		Empty(),
+		LineBreak(),
+		Annotate(Style{Bold: true}, Cursor()),
+		Annotate("id", Text("c")),
+		lazy(func() Doc { return Text("d") }),
+	})
+	actual, err := json.Marshal(doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `["a",{"type":"nest","indent":2,"contents":{"type":"group","contents":[{"type":"line"},{"type":"text","text":"b","length":2}]}},[],{"type":"line","soft":true},{"type":"annotation","style":{"bold":true},"contents":{"type":"cursor"}},{"type":"annotation","value":"id","contents":"c"},"d"]`
+	if string(actual) != expected {
+		t.Errorf("expected: %v, actual: %v", expected, string(actual))
+	}
+}
+
+func TestMarshalJSONError(t *testing.T) {
+	doc := Annotate(func() {}, Text("a"))
+	if _, err := json.Marshal(doc); err == nil {
+		t.Errorf("annotation which can't be encoded should be an error")
+	}
+}
+
+func TestUnmarshalDocRoundTrip(t *testing.T) {
+	sep := Concat([]Doc{Text(","), Line()})
+	docs := []Doc{
+		Empty(),
+		Text(""),
+		Text("test"),
+		TextWithLength("test", 1),
+		Line(),
+		LineBreak(),
+		Concat([]Doc{Text("a"), Text("b"), Text("c")}),
+		FoldDocs(func(a Doc, b Doc) Doc { return &concat{a: b, b: a} }, []Doc{Text("a"), Text("b"), Text("c")}),
+		Nest(uint(0), Text("a")),
+		BracketBy(Text("["), Text("]"), Intercalate(sep, []Doc{Text("a"), Text("b")}), uint(2)),
+		Fill(sep, []Doc{Text("a"), Group(Concat([]Doc{Text("b"), Line()})), Text("c")}),
+		IfBreak(Text(","), Empty()),
+		Align(Concat([]Doc{Text("a"), Line()})),
+		Annotate(Style{Italic: true, Color: "red"}, Text("a")),
+		Annotate(SourcePos{Source: "a.go", Line: 1, Column: 2, Name: "x"}, Text("a")),
+		Annotate(map[string]interface{}{"id": 1.0}, Text("a")),
+		Cursor(),
+	}
+	for _, doc := range docs {
+		b, err := json.Marshal(doc)
+		if err != nil {
+			t.Fatal(err)
+		}
+		actual, err := UnmarshalDoc(b)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !Equal(doc, actual) {
+			t.Errorf("expected: %v, actual: %v", doc, actual)
+		}
+	}
+}
+
+func TestUnmarshalDocGroup(t *testing.T) {
+	actual, err := UnmarshalDoc([]byte(`{"type": "group", "contents": ["a", {"type": "line"}, "b"]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := Group(Concat([]Doc{Text("a"), Line(), Text("b")}))
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("expected: %v, actual: %v", expected, actual)
+	}
+}
+
+func TestMarshalJSONFill(t *testing.T) {
+	doc := Fill(Concat([]Doc{Text(","), Line()}), []Doc{Text("a"), Text("b")})
+	actual, err := json.Marshal(doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `{"type":"fill","separator":[",",{"type":"line"}],"parts":["a","b"]}`
+	if string(actual) != expected {
+		t.Errorf("expected: %v, actual: %v", expected, string(actual))
+	}
+	decoded, err := UnmarshalDoc(actual)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !Equal(doc, decoded) {
+		t.Errorf("expected: %v, actual: %v", doc, decoded)
+	}
+}
+
+func TestUnmarshalDocError(t *testing.T) {
+	inputs := []string{
+		``,
+		`1`,
+		`{"type": "unknown"}`,
+		`{"type": "nest"}`,
+		`["a", {"type": "nest", "contents": 1}]`,
+		`{"type": "line"`,
+	}
+	for _, input := range inputs {
+		if _, err := UnmarshalDoc([]byte(input)); err == nil {
+			t.Errorf("%v should be an error", input)
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package prettier
+
+import (
+	"strings"
+)
+
+var latexEscaper = strings.NewReplacer(
+	`\`, `\textbackslash{}`,
+	`{`, `\{`,
+	`}`, `\}`,
+)
+
+// PrettyLaTeX renders the given Doc in the same way as Pretty,
+// as a LaTeX `alltt` environment (which requires the alltt package).
+//
+// Backslashes and braces are escaped, and Style annotations are mapped to
+// `\textbf`, `\textit`, `\underline` and `\textcolor` (which requires
+// the xcolor package).
+func PrettyLaTeX(width int, doc Doc) string {
+	var sb strings.Builder
+	sb.WriteString("\\begin{alltt}\n")
+	for _, line := range styledLines(best(width, uint(0), doc)) {
+		for _, run := range line {
+			s := latexEscaper.Replace(run.str)
+			for i := len(run.styles) - 1; i >= 0; i-- {
+				s = latexStyle(run.styles[i], s)
+			}
+			sb.WriteString(s)
+		}
+		sb.WriteString("\n")
+	}
+	sb.WriteString("\\end{alltt}")
+	return sb.String()
+}
+
+// PrettyVerbatim renders the given Doc in the same way as Pretty,
+// as a LaTeX `verbatim` environment.
+//
+// Style annotations are ignored since verbatim can't contain commands,
+// and the output must not contain `\end{verbatim}`.
+func PrettyVerbatim(width int, doc Doc) string {
+	return "\\begin{verbatim}\n" + Pretty(width, doc) + "\n\\end{verbatim}"
+}
+
+func latexStyle(style Style, s string) string {
+	if style.Bold {
+		s = `\textbf{` + s + `}`
+	}
+	if style.Italic {
+		s = `\textit{` + s + `}`
+	}
+	if style.Underline {
+		s = `\underline{` + s + `}`
+	}
+	if strings.HasPrefix(style.Color, "#") {
+		s = `\textcolor[HTML]{` + strings.ToUpper(style.Color[1:]) + `}{` + s + `}`
+	} else if style.Color != "" {
+		s = `\textcolor{` + style.Color + `}{` + s + `}`
+	}
+	return s
+}
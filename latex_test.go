@@ -0,0 +1,51 @@
+package prettier
+
+import (
+	"testing"
+)
+
+func styledTestDoc() Doc {
+	sep := Concat([]Doc{Text(","), LineOrSpace()})
+	ds := []Doc{
+		Annotate(Style{Bold: true}, Text(`\foo`)),
+		Annotate(Style{Color: "#ff0000"}, Concat([]Doc{
+			Text("{"),
+			Annotate(Style{Italic: true}, Text("bar")),
+			Text("}"),
+		})),
+		Text("100%"),
+	}
+	return TightBracketBy(Text("["), Text("]"), Intercalate(sep, ds), uint(2))
+}
+
+func TestPrettyLaTeX(t *testing.T) {
+	actual := PrettyLaTeX(40, styledTestDoc())
+	expected := `\begin{alltt}
+[\textbf{\textbackslash{}foo}, \textcolor[HTML]{FF0000}{\{}\textcolor[HTML]{FF0000}{\textit{bar}}\textcolor[HTML]{FF0000}{\}}, 100%]
+\end{alltt}`
+	if actual != expected {
+		t.Errorf("expected: %v, actual: %v", expected, actual)
+	}
+
+	actual = PrettyLaTeX(10, Annotate(Style{Underline: true, Color: "red"}, styledTestDoc()))
+	expected = `\begin{alltt}
+\textcolor{red}{\underline{[}}
+  \textcolor{red}{\underline{\textbf{\textbackslash{}foo}}}\textcolor{red}{\underline{,}}
+  \textcolor{red}{\underline{\textcolor[HTML]{FF0000}{\{}}}\textcolor{red}{\underline{\textcolor[HTML]{FF0000}{\textit{bar}}}}\textcolor{red}{\underline{\textcolor[HTML]{FF0000}{\}}}}\textcolor{red}{\underline{,}}
+  \textcolor{red}{\underline{100%}}
+\textcolor{red}{\underline{]}}
+\end{alltt}`
+	if actual != expected {
+		t.Errorf("expected: %v, actual: %v", expected, actual)
+	}
+}
+
+func TestPrettyVerbatim(t *testing.T) {
+	actual := PrettyVerbatim(40, styledTestDoc())
+	expected := `\begin{verbatim}
+[\foo, {bar}, 100%]
+\end{verbatim}`
+	if actual != expected {
+		t.Errorf("expected: %v, actual: %v", expected, actual)
+	}
+}
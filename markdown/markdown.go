@@ -0,0 +1,271 @@
+/*
+Package markdown lays out paragraphs, lists and tables of Markdown as
+documents of prettier, such as for generated changelogs.
+
+The words of a paragraph are filled in lines within the width. Code spans
+and links are never broken, hard breaks are kept, and the words which
+would start a block such as a list item or a heading at the start of
+a line are kept on the line of the previous word, so the reflowed text
+stays the same paragraph:
+
+	Fix the cache of `Render` when the width is changed. See
+	[#12](https://example.com/issues/12) for the details.
+
+The columns of a table are padded to the widest cells, and aligned as
+their delimiter rows specify:
+
+	| Version | Date       |
+	| ------- | ---------- |
+	| v1.2.0  | 2021-04-01 |
+*/
+package markdown
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	p "github.com/tanishiking/prettier"
+)
+
+// Paragraph lays out the inline Markdown text as a paragraph whose words
+// are filled in lines. The whitespace between the words is collapsed, and
+// a line ending with a backslash or two spaces is a hard break, which is
+// written as a backslash.
+func Paragraph(text string) p.Doc {
+	ds := []p.Doc{}
+	for _, ws := range lines(text) {
+		parts := make([]p.Doc, len(ws))
+		for i, w := range ws {
+			parts[i] = p.Text(w)
+		}
+		if len(ds) > 0 {
+			ds = append(ds, p.LineBreak())
+		}
+		ds = append(ds, p.Fill(p.Line(), parts))
+	}
+	return p.Concat(ds)
+}
+
+// lines splits the text into the words of the lines separated by hard
+// breaks. The last word of each line except for the last one ends with
+// a backslash.
+func lines(text string) [][]string {
+	ls := [][]string{}
+	words := []string{}
+	var word strings.Builder
+	// end adds the word, which is joined with the previous word if it
+	// would start a block at the start of a line.
+	end := func() {
+		if word.Len() == 0 {
+			return
+		}
+		w := word.String()
+		word.Reset()
+		if len(words) > 0 && isMarker(w) {
+			words[len(words)-1] += " " + w
+			return
+		}
+		words = append(words, w)
+	}
+	for i := 0; i < len(text); {
+		c := text[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			j := i
+			for j < len(text) && strings.IndexByte(" \t\n\r", text[j]) >= 0 {
+				j++
+			}
+			space := text[i:j]
+			if nl := strings.IndexByte(space, '\n'); nl >= 0 && j < len(text) && len(words)+word.Len() > 0 {
+				hard := strings.HasPrefix(space[:nl], "  ")
+				if w := word.String(); strings.HasSuffix(w, "\\") && !strings.HasSuffix(w, "\\\\") && nl == 0 {
+					hard = true
+					word.Reset()
+					word.WriteString(w[:len(w)-1])
+				}
+				if hard {
+					end()
+					if len(words) > 0 {
+						words[len(words)-1] += "\\"
+						ls = append(ls, words)
+						words = []string{}
+					}
+				}
+			}
+			end()
+			i = j
+		case c == '\\' && i+1 < len(text) && isPunct(text[i+1]):
+			word.WriteString(text[i : i+2])
+			i += 2
+		case c == '`':
+			n := code(text[i:])
+			word.WriteString(strings.Replace(text[i:i+n], "\n", " ", -1))
+			i += n
+		case c == '[' || c == '!' && strings.HasPrefix(text[i:], "!["):
+			n := link(text[i:])
+			word.WriteString(collapse(text[i : i+n]))
+			i += n
+		default:
+			word.WriteByte(c)
+			i++
+		}
+	}
+	end()
+	if len(words) > 0 {
+		ls = append(ls, words)
+	}
+	return ls
+}
+
+// code is the length of the code span at the start of the text, or the
+// length of its backticks if they aren't closed.
+func code(text string) int {
+	n := len(text) - len(strings.TrimLeft(text, "`"))
+	for i := n; i < len(text); {
+		if text[i] != '`' {
+			i++
+			continue
+		}
+		m := len(text[i:]) - len(strings.TrimLeft(text[i:], "`"))
+		if m == n {
+			return i + m
+		}
+		i += m
+	}
+	return n
+}
+
+// link is the length of the inline link or image at the start of the
+// text, or the length of the opening bracket if it isn't a link.
+func link(text string) int {
+	start := strings.IndexByte(text, '[')
+	end := closing(text, start, '[', ']')
+	if end < 0 || end+1 >= len(text) || text[end+1] != '(' {
+		return start + 1
+	}
+	if dest := closing(text, end+1, '(', ')'); dest >= 0 {
+		return dest + 1
+	}
+	return start + 1
+}
+
+// closing is the index of the bracket closing the bracket at start, or
+// -1 if it isn't closed. Escaped brackets and code spans are skipped.
+func closing(text string, start int, open, close byte) int {
+	depth := 0
+	for i := start; i < len(text); i++ {
+		switch text[i] {
+		case '\\':
+			i++
+		case '`':
+			i += code(text[i:]) - 1
+		case open:
+			depth++
+		case close:
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// isPunct reports whether the character is an ASCII punctuation, which
+// can be escaped by a backslash.
+func isPunct(c byte) bool {
+	return strings.IndexByte("!\"#$%&'()*+,-./:;<=>?@[\\]^_`{|}~", c) >= 0
+}
+
+// collapse replaces the whitespace in the text with single spaces.
+func collapse(text string) string {
+	return strings.Join(strings.Fields(text), " ")
+}
+
+// isMarker reports whether the word starts a block at the start of
+// a line, such as a list item, a heading, a block quote, a thematic break,
+// the underline of a setext heading or an HTML block.
+func isMarker(word string) bool {
+	switch {
+	case isHTML(word):
+		return true
+	case strings.HasPrefix(word, ">"), strings.HasPrefix(word, "```"), strings.HasPrefix(word, "~~~"):
+		return true
+	case strings.Trim(word, "#") == "" && len(word) <= 6:
+		return true
+	case strings.Trim(word, word[:1]) == "" && strings.Contains("-+*_=", word[:1]):
+		return true
+	}
+	digits := strings.TrimLeft(word, "0123456789")
+	return len(digits) == 1 && len(word) > 1 && len(word) <= 10 && (digits == "." || digits == ")")
+}
+
+// htmlBlocks is the names of the tags which start HTML blocks interrupting
+// paragraphs in CommonMark.
+var htmlBlocks = map[string]bool{
+	"address": true, "article": true, "aside": true, "base": true,
+	"basefont": true, "blockquote": true, "body": true, "caption": true,
+	"center": true, "col": true, "colgroup": true, "dd": true,
+	"details": true, "dialog": true, "dir": true, "div": true, "dl": true,
+	"dt": true, "fieldset": true, "figcaption": true, "figure": true,
+	"footer": true, "form": true, "frame": true, "frameset": true,
+	"h1": true, "h2": true, "h3": true, "h4": true, "h5": true, "h6": true,
+	"head": true, "header": true, "hr": true, "html": true, "iframe": true,
+	"legend": true, "li": true, "link": true, "main": true, "menu": true,
+	"menuitem": true, "nav": true, "noframes": true, "ol": true,
+	"optgroup": true, "option": true, "p": true, "param": true,
+	"search": true, "section": true, "summary": true, "table": true,
+	"tbody": true, "td": true, "tfoot": true, "th": true, "thead": true,
+	"title": true, "tr": true, "track": true, "ul": true,
+	// the tags whose contents are raw.
+	"pre": true, "script": true, "style": true, "textarea": true,
+}
+
+// isHTML reports whether the word starts an HTML block, such as a tag of
+// htmlBlocks, a comment, a processing instruction or a declaration.
+func isHTML(word string) bool {
+	switch {
+	case !strings.HasPrefix(word, "<"):
+		return false
+	case strings.HasPrefix(word, "<!"), strings.HasPrefix(word, "<?"):
+		return true
+	}
+	name := strings.TrimPrefix(word[1:], "/")
+	n := len(name) - len(strings.TrimLeft(name, "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"))
+	rest := name[n:]
+	return htmlBlocks[strings.ToLower(name[:n])] && (rest == "" || strings.HasPrefix(rest, ">") || strings.HasPrefix(rest, "/>"))
+}
+
+// ListItem lays out a list item whose marker is such as "-" or "1.". The
+// blocks of the item are separated by blank lines, and their lines are
+// indented by the width of the marker and a space.
+func ListItem(marker string, blocks ...p.Doc) p.Doc {
+	if len(blocks) == 0 {
+		return p.Text(marker)
+	}
+	indent := uint(utf8.RuneCountInString(marker) + 1)
+	return p.Concat([]p.Doc{p.Text(marker + " "), p.Nest(indent, Blocks(blocks...))})
+}
+
+// List lays out the items of a tight list on separate lines.
+func List(items ...p.Doc) p.Doc {
+	return p.Intercalate(p.LineBreak(), items)
+}
+
+// Blocks lays out the blocks such as paragraphs, lists and tables,
+// separated by blank lines.
+func Blocks(blocks ...p.Doc) p.Doc {
+	return p.Intercalate(p.Concat([]p.Doc{p.LineBreak(), p.LineBreak()}), blocks)
+}
+
+// Pretty renders the Markdown within the width like prettier.Pretty, and
+// removes the spaces indenting the blank lines in list items.
+func Pretty(width int, doc p.Doc) string {
+	ls := strings.Split(p.Pretty(width, doc), "\n")
+	for i, l := range ls {
+		if strings.TrimLeft(l, " ") == "" {
+			ls[i] = ""
+		}
+	}
+	return strings.Join(ls, "\n")
+}
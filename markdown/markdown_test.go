@@ -0,0 +1,105 @@
+package markdown
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	p "github.com/tanishiking/prettier"
+)
+
+func TestParagraph(t *testing.T) {
+	text := "Fix the cache of `Render (width int)` when the width is changed.\nSee [the issue #12](https://example.com/issues/12) for the details."
+	tests := []struct {
+		width    int
+		expected string
+	}{
+		{200, "Fix the cache of `Render (width int)` when the width is changed. See [the issue #12](https://example.com/issues/12) for the details."},
+		{40, "Fix the cache of `Render (width int)`\nwhen the width is changed. See\n[the issue #12](https://example.com/issues/12)\nfor the details."},
+	}
+	for _, tt := range tests {
+		if actual := Pretty(tt.width, Paragraph(text)); actual != tt.expected {
+			t.Errorf("expected: %v, actual: %v", tt.expected, actual)
+		}
+	}
+}
+
+func TestLines(t *testing.T) {
+	tests := []struct {
+		text     string
+		expected [][]string
+	}{
+		{"a  b\n\tc", [][]string{{"a", "b", "c"}}},
+		{"a b  \nc\\\nd e", [][]string{{"a", "b\\"}, {"c\\"}, {"d", "e"}}},
+		{"a b  \n", [][]string{{"a", "b"}}},
+		{"a\\ b \\` c", [][]string{{"a\\", "b", "\\`", "c"}}},
+		{"``a ` b``, `c\nd` `e", [][]string{{"``a ` b``,", "`c d`", "`e"}}},
+		{"![an\n image](a.png) [a] (b) [c]", [][]string{{"![an image](a.png)", "[a]", "(b)", "[c]"}}},
+		{"[a [b] `]`](c (d)).", [][]string{{"[a [b] `]`](c (d))."}}},
+	}
+	for _, tt := range tests {
+		if actual := lines(tt.text); !reflect.DeepEqual(actual, tt.expected) {
+			t.Errorf("%q: expected: %q, actual: %q", tt.text, tt.expected, actual)
+		}
+	}
+}
+
+func TestLinesMarkers(t *testing.T) {
+	text := "a - b + c * d # e ###### f ####### g > h >i ``` j ~~~k 1. l 2) m 1.5 n --- o == p __ q"
+	expected := [][]string{{"a -", "b +", "c *", "d #", "e ######", "f", "#######", "g >", "h >i ```", "j ~~~k 1.", "l 2)", "m", "1.5", "n ---", "o ==", "p __", "q"}}
+	if actual := lines(text); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("expected: %q, actual: %q", expected, actual)
+	}
+}
+
+func TestLinesHTML(t *testing.T) {
+	text := "a <div> b <!-- c --> d </P> e <pre>x</pre> f <?php g <![CDATA[ h <span> i <divs> j <br/>"
+	expected := [][]string{{"a <div>", "b <!--", "c", "-->", "d </P>", "e <pre>x</pre>", "f <?php", "g <![CDATA[", "h", "<span>", "i", "<divs>", "j", "<br/>"}}
+	if actual := lines(text); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("expected: %q, actual: %q", expected, actual)
+	}
+	// the tags never start lines.
+	paragraph := "aaaaaaa <div>\nbbb <!-- c\n-->"
+	if actual := Pretty(10, Paragraph("aaaaaaa <div> bbb <!-- c -->")); actual != paragraph {
+		t.Errorf("expected: %v, actual: %v", paragraph, actual)
+	}
+}
+
+func TestList(t *testing.T) {
+	doc := Blocks(
+		List(
+			ListItem("-", Paragraph("Add the markdown package with paragraphs, lists and tables."),
+				List(
+					ListItem("1.", Paragraph("Paragraphs are filled in lines.")),
+					ListItem("2.", Paragraph("Lists are indented.")),
+				),
+			),
+			ListItem("-"),
+			ListItem("10.", Paragraph("Wide markers indent more than the others.")),
+		),
+		Paragraph("The end."),
+	)
+	expected := `- Add the markdown package with
+  paragraphs, lists and tables.
+
+  1. Paragraphs are filled in lines.
+  2. Lists are indented.
+-
+10. Wide markers indent more than the
+    others.
+
+The end.`
+	if actual := Pretty(40, doc); actual != expected {
+		t.Errorf("expected: %v, actual: %v", expected, actual)
+	}
+}
+
+func ExampleParagraph() {
+	doc := ListItem("-", Paragraph("Fix `Pretty` for the documents whose width is larger than the width - see #12."))
+	fmt.Println(p.Pretty(30, doc))
+	// Output:
+	// - Fix `Pretty` for the
+	//   documents whose width is
+	//   larger than the width - see
+	//   #12.
+}
@@ -0,0 +1,115 @@
+package markdown
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	p "github.com/tanishiking/prettier"
+)
+
+// Alignment is the alignment of a column of a table.
+type Alignment int
+
+// Alignments of the columns.
+const (
+	// AlignNone writes the delimiter without colons, and pads the cells on
+	// the right like AlignLeft.
+	AlignNone Alignment = iota
+	AlignLeft
+	AlignCenter
+	AlignRight
+)
+
+// Table lays out a table of GitHub Flavored Markdown with the header and
+// the rows, whose columns are aligned by aligns. The missing alignments
+// are AlignNone, and the missing cells are empty.
+//
+// The cells are padded to the widest cell of their columns. The pipes in
+// the cells are escaped, and the newlines are replaced with spaces.
+func Table(header []string, aligns []Alignment, rows [][]string) p.Doc {
+	n := len(header)
+	for _, row := range rows {
+		if len(row) > n {
+			n = len(row)
+		}
+	}
+	cells := make([][]string, len(rows)+1)
+	for i, row := range append([][]string{header}, rows...) {
+		cells[i] = make([]string, n)
+		for j, c := range row {
+			cells[i][j] = cell(c)
+		}
+	}
+	aligns = append(aligns, make([]Alignment, n)...)[:n]
+	widths := make([]int, n)
+	for j := range widths {
+		// the delimiter has at least three characters.
+		widths[j] = 3
+		for _, row := range cells {
+			if w := utf8.RuneCountInString(row[j]); w > widths[j] {
+				widths[j] = w
+			}
+		}
+	}
+	delimiter := make([]string, n)
+	for j, w := range widths {
+		delimiter[j] = rule(aligns[j], w)
+	}
+	ds := []p.Doc{p.Text(join(cells[0], aligns, widths)), p.Text(join(delimiter, aligns, widths))}
+	for _, row := range cells[1:] {
+		ds = append(ds, p.Text(join(row, aligns, widths)))
+	}
+	return p.Intercalate(p.LineBreak(), ds)
+}
+
+// cell escapes the pipes in the text of the cell, which is on one line.
+func cell(text string) string {
+	var b strings.Builder
+	for i := 0; i < len(text); i++ {
+		switch c := text[i]; {
+		case c == '\\' && i+1 < len(text):
+			b.WriteString(text[i : i+2])
+			i++
+		case c == '|':
+			b.WriteString(`\|`)
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return collapse(b.String())
+}
+
+// rule is the delimiter of the column of the width.
+func rule(align Alignment, width int) string {
+	switch align {
+	case AlignLeft:
+		return ":" + strings.Repeat("-", width-1)
+	case AlignCenter:
+		return ":" + strings.Repeat("-", width-2) + ":"
+	case AlignRight:
+		return strings.Repeat("-", width-1) + ":"
+	}
+	return strings.Repeat("-", width)
+}
+
+// join writes the row, padding the cells to the widths of the columns.
+func join(row []string, aligns []Alignment, widths []int) string {
+	var b strings.Builder
+	b.WriteString("|")
+	for j, c := range row {
+		pad := widths[j] - utf8.RuneCountInString(c)
+		left := 0
+		switch aligns[j] {
+		case AlignCenter:
+			left = pad / 2
+		case AlignRight:
+			left = pad
+		}
+		b.WriteString(" ")
+		b.WriteString(strings.Repeat(" ", left))
+		b.WriteString(c)
+		b.WriteString(strings.Repeat(" ", pad-left))
+		b.WriteString(" |")
+	}
+	return b.String()
+}
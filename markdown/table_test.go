@@ -0,0 +1,50 @@
+package markdown
+
+import (
+	"fmt"
+	"testing"
+
+	p "github.com/tanishiking/prettier"
+)
+
+func TestTable(t *testing.T) {
+	header := []string{"Version", "Date", "Notes"}
+	aligns := []Alignment{AlignLeft, AlignCenter, AlignRight}
+	rows := [][]string{
+		{"v1.2.0", "2021-04-01", "a|b"},
+		{"v1.1.0", "café"},
+		{"v1", "x", "multi\nline", `c\|d`},
+	}
+	expected := `| Version |    Date    |      Notes |      |
+| :------ | :--------: | ---------: | ---- |
+| v1.2.0  | 2021-04-01 |       a\|b |      |
+| v1.1.0  |    café    |            |      |
+| v1      |     x      | multi line | c\|d |`
+	if actual := p.Pretty(80, Table(header, aligns, rows)); actual != expected {
+		t.Errorf("expected: %v, actual: %v", expected, actual)
+	}
+}
+
+func TestTableNarrow(t *testing.T) {
+	expected := `| a   |   b |
+| --- | --: |
+| 1   |   2 |`
+	doc := Table([]string{"a", "b"}, []Alignment{AlignNone, AlignRight}, [][]string{{"1", "2"}})
+	if actual := p.Pretty(1, doc); actual != expected {
+		t.Errorf("expected: %v, actual: %v", expected, actual)
+	}
+}
+
+func ExampleTable() {
+	doc := Table(
+		[]string{"Package", "Lines"},
+		[]Alignment{AlignLeft, AlignRight},
+		[][]string{{"json", "120"}, {"markdown", "80"}},
+	)
+	fmt.Println(p.Pretty(80, doc))
+	// Output:
+	// | Package  | Lines |
+	// | :------- | ----: |
+	// | json     |   120 |
+	// | markdown |    80 |
+}
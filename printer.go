@@ -8,9 +8,54 @@ import (
 	"fmt"
 )
 
+// document is a Doc being laid out by be, with its indentation and
+// whether it is laid out flat (lines are replaced with spaces or nothing).
 type document struct {
-	col uint
-	doc Doc
+	col  uint
+	flat bool
+	doc  Doc
+}
+
+// documents is an immutable stack of documents,
+// so that alternative layouts can share the rest of the documents.
+type documents struct {
+	head *document
+	tail *documents
+}
+
+func push(d *document, tail *documents) *documents {
+	return &documents{head: d, tail: tail}
+}
+
+// annotationEnd marks the end of an annotated document
+// in the documents being laid out by be.
+type annotationEnd struct {
+	value interface{}
+}
+
+func (a *annotationEnd) String() string {
+	return fmt.Sprintf("AnnotationEnd(%v)", a.value)
+}
+
+func (a *annotationEnd) flattenBool() (Doc, bool) {
+	return a, false
+}
+
+// fillParts is the rest of the parts of a fill being laid out by be.
+// If flatFirst is set, the first part is laid out flat since
+// the separator before it is flattened.
+type fillParts struct {
+	sep       Doc
+	parts     []Doc
+	flatFirst bool
+}
+
+func (f *fillParts) String() string {
+	return (&fill{sep: f.sep, parts: f.parts}).String()
+}
+
+func (f *fillParts) flattenBool() (Doc, bool) {
+	return (&fill{sep: f.sep, parts: f.parts}).flattenBool()
 }
 
 // Pretty renders the given Doc as a string, limiting line lengths to
@@ -20,95 +65,242 @@ type document struct {
 // longer than `width` -- it just attempts to keep lines within this
 // length when possible.
 func Pretty(width int, doc Doc) string {
-	return best(width, uint(0), doc).layout()
+	return render(best(width, uint(0), doc)).Output
+}
+
+// printer lays out documents within width.
+type printer struct {
+	width int
+	// trace records the decisions made by choose as traceChunk.
+	trace bool
+	// cache is the widths of documents laid out flat, if any.
+	cache *cache
 }
 
 func best(width int, k uint, d Doc) chunk {
-	return be(
-		width,
-		k,
-		[]*document{
-			&document{col: uint(0), doc: d},
-		},
-	)
+	p := &printer{width: width, cache: newCache()}
+	return p.best(k, d)
 }
 
-func be(width int, k uint, x []*document) chunk {
-	if len(x) == 0 {
-		return &emptyChunk{}
-	} else if _, ok := x[0].doc.(*empty); ok {
-		return be(width, k, x[1:])
-	} else if v, ok := x[0].doc.(*concat); ok {
-		i := x[0].col
-		return be(
-			width,
-			k,
-			append([]*document{
-				&document{col: i, doc: v.a},
-				&document{col: i, doc: v.b},
-			}, x[1:]...),
-		)
-	} else if v, ok := x[0].doc.(*text); ok {
-		s := v.str
-		chunk := be(width, k+uint(v.length), x[1:])
-		return &textChunk{
-			str:       s,
-			strLength: v.length,
-			c:         chunk,
-		}
-	} else if v, ok := x[0].doc.(*nest); ok {
-		i := x[0].col
-		indent := v.indent
-		return be(
-			width,
-			k,
-			append([]*document{&document{col: i + indent, doc: v.doc}}, x[1:]...),
-		)
-	} else if _, ok := x[0].doc.(*line); ok {
-		i := x[0].col
-		chunk := be(width, i, x[1:])
-		return &lineChunk{
-			indent: i,
-			c:      chunk,
-		}
-	} else if v, ok := x[0].doc.(*union); ok {
-		i := x[0].col
-		// Since it is redundant to caluculate if the first candidate fits
-		// if (w - k) < 0, check if w - k < 0 and if it is true,
-		// skip the caluculation and caluculate second candidate.
-		if width-int(k) < 0 {
-			second := be(
-				width,
+func (p *printer) best(k uint, d Doc) chunk {
+	return p.be(k, push(&document{col: uint(0), doc: d}, nil))
+}
+
+// be lays out the documents x from the column k, until it emits a chunk.
+// The rest of the layout is laid out lazily, so that checking if
+// a layout fits only lays out its first line.
+func (p *printer) be(k uint, x *documents) chunk {
+	for x != nil {
+		d := x.head
+		i := d.col
+		switch v := d.doc.(type) {
+		case *empty:
+			x = x.tail
+		case *concat:
+			x = push(
+				&document{col: i, flat: d.flat, doc: v.a},
+				push(&document{col: i, flat: d.flat, doc: v.b}, x.tail),
+			)
+		case *text:
+			return &textChunk{
+				str:       v.str,
+				strLength: v.length,
+				c:         p.later(k+uint(v.length), x.tail),
+			}
+		case *nest:
+			x = push(&document{col: i + v.indent, flat: d.flat, doc: v.doc}, x.tail)
+		case *align:
+			x = push(&document{col: k, flat: d.flat, doc: v.doc}, x.tail)
+		case *line:
+			if !d.flat {
+				return &lineChunk{
+					indent: i,
+					c:      p.later(i, x.tail),
+				}
+			}
+			if !v.flattenToSpace {
+				x = x.tail
+				continue
+			}
+			return &textChunk{
+				str:       " ",
+				strLength: 1,
+				c:         p.later(k+1, x.tail),
+			}
+		case *group:
+			if d.flat {
+				x = push(&document{col: i, flat: true, doc: v.doc}, x.tail)
+				continue
+			}
+			if p.exceeds(k, v) {
+				x = push(&document{col: i, flat: false, doc: v.doc}, x.tail)
+				continue
+			}
+			return p.choose(
 				k,
-				append([]*document{&document{col: i, doc: v.b}}, x[1:]...),
+				push(&document{col: i, flat: true, doc: v.doc}, x.tail),
+				push(&document{col: i, flat: false, doc: v.doc}, x.tail),
 			)
-			return second
+		case *ifBreak:
+			if d.flat {
+				x = push(&document{col: i, flat: true, doc: v.flat}, x.tail)
+			} else {
+				x = push(&document{col: i, doc: v.broken}, x.tail)
+			}
+		case *fill:
+			x = push(&document{col: i, flat: d.flat, doc: &fillParts{sep: v.sep, parts: v.parts}}, x.tail)
+		case *fillParts:
+			if len(v.parts) == 1 {
+				x = push(&document{col: i, flat: d.flat || v.flatFirst, doc: v.parts[0]}, x.tail)
+				continue
+			}
+			rest := &fillParts{sep: v.sep, parts: v.parts[1:]}
+			if d.flat {
+				x = push(
+					&document{col: i, flat: true, doc: v.parts[0]},
+					push(
+						&document{col: i, flat: true, doc: v.sep},
+						push(&document{col: i, flat: true, doc: rest}, x.tail),
+					),
+				)
+				continue
+			}
+			// the first part and the separator are laid out flat if the
+			// next part also fits on the line when it is laid out flat.
+			if p.exceeds(k, v.parts[0], v.sep, v.parts[1]) {
+				x = push(
+					&document{col: i, flat: v.flatFirst, doc: v.parts[0]},
+					push(
+						&document{col: i, doc: v.sep},
+						push(&document{col: i, doc: rest}, x.tail),
+					),
+				)
+				continue
+			}
+			flatRest := &fillParts{sep: v.sep, parts: v.parts[1:], flatFirst: true}
+			return p.choose(
+				k,
+				push(
+					&document{col: i, flat: true, doc: v.parts[0]},
+					push(
+						&document{col: i, flat: true, doc: v.sep},
+						push(&document{col: i, doc: flatRest}, x.tail),
+					),
+				),
+				push(
+					&document{col: i, flat: v.flatFirst, doc: v.parts[0]},
+					push(
+						&document{col: i, doc: v.sep},
+						push(&document{col: i, doc: rest}, x.tail),
+					),
+				),
+			)
+		case *union:
+			// `a` is the flattened layout of `b`, though it may still
+			// contain alternative layouts.
+			if d.flat {
+				x = push(&document{col: i, flat: true, doc: v.a}, x.tail)
+				continue
+			}
+			return p.choose(
+				k,
+				push(&document{col: i, doc: v.a}, x.tail),
+				push(&document{col: i, doc: v.b}, x.tail),
+			)
+		case *annotation:
+			return &annotationChunk{
+				value: v.value,
+				start: true,
+				c: p.later(k, push(
+					&document{col: i, flat: d.flat, doc: v.doc},
+					push(&document{col: i, doc: &annotationEnd{value: v.value}}, x.tail),
+				)),
+			}
+		case *annotationEnd:
+			return &annotationChunk{
+				value: v.value,
+				start: false,
+				c:     p.later(k, x.tail),
+			}
+		case *cursor:
+			return &cursorChunk{
+				c: p.later(k, x.tail),
+			}
+		case *lazyDoc:
+			x = push(&document{col: i, flat: d.flat, doc: v.Evaluated()}, x.tail)
+		default:
+			panic(fmt.Sprintf("Error: %v sould not be here", v))
 		}
-		first := be(
-			width,
-			k,
-			append([]*document{&document{col: i, doc: v.a}}, x[1:]...),
-		)
-		// do not evaluate `v.b` until confirm that first doesn't fits
-		// in case `v.b` is lazydoc
-		if first.fits(width - int(k)) {
-			return first
+	}
+	return &emptyChunk{}
+}
+
+// later lays out the documents x from the column k when it is needed.
+func (p *printer) later(k uint, x *documents) chunk {
+	if x == nil {
+		return &emptyChunk{}
+	}
+	return &lazyChunk{
+		f: func() chunk {
+			return p.be(k, x)
+		},
+	}
+}
+
+// choose lays out the first documents if their first line fits within
+// the width, and the second documents otherwise.
+func (p *printer) choose(k uint, first *documents, second *documents) chunk {
+	// Since it is redundant to caluculate if the first candidate fits
+	// if (w - k) < 0, check if w - k < 0 and if it is true,
+	// skip the caluculation and caluculate second candidate.
+	if p.width-int(k) < 0 {
+		return p.traced(k, false, "", p.be(k, second))
+	}
+	firstChunk := p.be(k, first)
+	// do not evaluate `second` until confirm that first doesn't fits
+	// in case `second` is lazydoc
+	if firstChunk.fits(p.width - int(k)) {
+		return p.traced(k, true, "", firstChunk)
+	}
+	overflow := ""
+	if p.trace {
+		overflow = overflowText(firstChunk, p.width-int(k))
+	}
+	return p.traced(k, false, overflow, p.be(k, second))
+}
+
+// exceeds reports whether the documents laid out flat from the column k
+// are known to be wider than the width with the cache, in which case
+// choose would not choose them.
+func (p *printer) exceeds(k uint, docs ...Doc) bool {
+	if p.cache == nil || p.trace {
+		// the decisions are traced with the text which overflows.
+		return false
+	}
+	width := 0
+	for _, doc := range docs {
+		w := p.cache.flatWidth(doc)
+		if w < 0 {
+			return false
 		}
-		second := be(
-			width,
-			k,
-			append([]*document{&document{col: i, doc: v.b}}, x[1:]...),
-		)
-		return second
-	} else if v, ok := x[0].doc.(lazyDoc); ok {
-		i := x[0].col
-		return be(
-			width,
-			k,
-			append([]*document{&document{col: i, doc: v.Evaluated()}}, x[1:]...),
-		)
-	} else {
-		v := x[0].doc
-		panic(fmt.Sprintf("Error: %v sould not be here", v))
+		width += w
+	}
+	return width > p.width-int(k)
+}
+
+// traced prepends the decision made by choose at column k to the
+// chosen layout c, when the printer traces decisions.
+func (p *printer) traced(k uint, flat bool, overflow string, c chunk) chunk {
+	if !p.trace {
+		return c
+	}
+	return &traceChunk{
+		decision: Decision{
+			Column:    int(k),
+			Remaining: p.width - int(k),
+			Flat:      flat,
+			Overflow:  overflow,
+		},
+		c: c,
 	}
 }
@@ -0,0 +1,225 @@
+/*
+Package protobuf formats the Protocol Buffers text format and .proto files
+with prettier.
+
+A message of the text format is written on one line if it fits, and
+otherwise each of its fields is on its own line:
+
+	server { host: "localhost" port: 8080 }
+	users {
+	  name: "alice"
+	  roles: ["admin", "dev"]
+	}
+
+The angle brackets of messages are written as braces, and the optional
+separators between fields are removed. With Options.Align, the values of
+consecutive scalar fields of a message broken into lines are aligned, and
+so are the numbers of consecutive fields and enum values of a .proto
+file. Comments and single blank lines between fields are kept.
+*/
+package protobuf
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	p "github.com/tanishiking/prettier"
+)
+
+// Options configures how the text format and .proto files are formatted.
+type Options struct {
+	// Indent is the indentation of the fields of messages. Zero means 2.
+	Indent uint
+	// Align aligns the values of consecutive fields on separate lines.
+	Align bool
+}
+
+// Format formats the message in the text format within the width when
+// possible.
+func Format(src string, width int, opts Options) (string, error) {
+	doc, err := Doc(src, opts)
+	if err != nil {
+		return "", err
+	}
+	return trim(p.Pretty(width, doc)), nil
+}
+
+// Doc lays out the message in the text format, one top-level field on each
+// line.
+func Doc(src string, opts Options) (p.Doc, error) {
+	toks, err := Tokenize(src)
+	if err != nil {
+		return nil, err
+	}
+	ps := &parser{toks: toks}
+	m, err := ps.message("")
+	if err != nil {
+		return nil, err
+	}
+	return newLayout(opts).fields(m, true), nil
+}
+
+// FormatSchema formats the .proto file within the width when possible.
+func FormatSchema(src string, width int, opts Options) (string, error) {
+	doc, err := SchemaDoc(src, opts)
+	if err != nil {
+		return "", err
+	}
+	return trim(p.Pretty(width, doc)), nil
+}
+
+// SchemaDoc lays out the .proto file, one statement on each line. The
+// lists of field options and the message values of options are broken
+// into lines when they don't fit.
+func SchemaDoc(src string, opts Options) (p.Doc, error) {
+	toks, err := Tokenize(src)
+	if err != nil {
+		return nil, err
+	}
+	ps := &parser{toks: toks}
+	b, err := ps.block("")
+	if err != nil {
+		return nil, err
+	}
+	return newLayout(opts).statements(b), nil
+}
+
+// trim removes the spaces indenting the blank lines.
+func trim(s string) string {
+	lines := strings.Split(s, "\n")
+	for i, line := range lines {
+		if strings.TrimLeft(line, " ") == "" {
+			lines[i] = ""
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+type parser struct {
+	toks []Token
+	pos  int
+	// line is the last line of the last token.
+	line int
+}
+
+func (ps *parser) errorf(format string, args ...interface{}) error {
+	msg := fmt.Sprintf(format, args...)
+	if ps.pos >= len(ps.toks) {
+		return fmt.Errorf("protobuf: %s at the end", msg)
+	}
+	tok := ps.toks[ps.pos]
+	return fmt.Errorf("protobuf: %d:%d: %s", tok.Line, tok.Col, msg)
+}
+
+// peek is the next token, or nil at the end.
+func (ps *parser) peek() *Token {
+	if ps.pos < len(ps.toks) {
+		return &ps.toks[ps.pos]
+	}
+	return nil
+}
+
+// at reports whether the next token is the punctuation.
+func (ps *parser) at(punct string) bool {
+	tok := ps.peek()
+	return tok != nil && tok.Kind == KindPunct && tok.Text == punct
+}
+
+func (ps *parser) next() Token {
+	tok := ps.toks[ps.pos]
+	ps.pos++
+	ps.line = tok.Line + tok.lines() - 1
+	return tok
+}
+
+// expect advances over the punctuation.
+func (ps *parser) expect(punct string) error {
+	if !ps.at(punct) {
+		if ps.peek() == nil {
+			return ps.errorf("expected %q", punct)
+		}
+		return ps.errorf("expected %q, found %q", punct, ps.peek().Text)
+	}
+	ps.next()
+	return nil
+}
+
+// trail advances over the comments on the line of the last token.
+func (ps *parser) trail() []Token {
+	comments := []Token{}
+	for tok := ps.peek(); tok != nil && tok.Kind == KindComment && tok.Line == ps.line; tok = ps.peek() {
+		comments = append(comments, ps.next())
+	}
+	return comments
+}
+
+// lead advances over the comments.
+func (ps *parser) lead() []Token {
+	comments := []Token{}
+	for tok := ps.peek(); tok != nil && tok.Kind == KindComment; tok = ps.peek() {
+		comments = append(comments, ps.next())
+	}
+	return comments
+}
+
+type layout struct {
+	opts Options
+}
+
+func newLayout(opts Options) *layout {
+	if opts.Indent == 0 {
+		opts.Indent = 2
+	}
+	return &layout{opts: opts}
+}
+
+// lead lays out the comments on the lines before a node starting at line,
+// keeping a blank line between them.
+func (l *layout) lead(comments []Token, line int) p.Doc {
+	ds := []p.Doc{}
+	for i, c := range comments {
+		ds = append(ds, comment(c), p.LineBreak())
+		next := line
+		if i+1 < len(comments) {
+			next = comments[i+1].Line
+		}
+		if next-(c.Line+c.lines()-1) > 1 {
+			ds = append(ds, p.LineBreak())
+		}
+	}
+	return p.Concat(ds)
+}
+
+// trail lays out the comments following a node on its line.
+func (l *layout) trail(comments []Token) p.Doc {
+	ds := []p.Doc{}
+	for _, c := range comments {
+		ds = append(ds, p.Text(" "), comment(c))
+	}
+	return p.Concat(ds)
+}
+
+// end lays out the comments at the end of a block, each preceded by
+// a line break. The blank lines between the comments and after the last
+// node of the block ending at line are kept, unless line is zero.
+func (l *layout) end(comments []Token, line int) p.Doc {
+	ds := []p.Doc{}
+	for _, c := range comments {
+		ds = append(ds, p.LineBreak())
+		if line > 0 && c.Line-line > 1 {
+			ds = append(ds, p.LineBreak())
+		}
+		ds = append(ds, comment(c))
+		line = c.Line + c.lines() - 1
+	}
+	return p.Concat(ds)
+}
+
+// comment is the comment whose first line is counted in the width.
+func comment(c Token) p.Doc {
+	if i := strings.IndexByte(c.Text, '\n'); i >= 0 {
+		return p.TextWithLength(c.Text, utf8.RuneCountInString(c.Text[:i]))
+	}
+	return p.Text(c.Text)
+}
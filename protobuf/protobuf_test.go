@@ -0,0 +1,180 @@
+package protobuf
+
+import (
+	"fmt"
+	"testing"
+)
+
+func format(t *testing.T, src string, width int, opts Options) string {
+	t.Helper()
+	actual, err := Format(src, width, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return actual
+}
+
+const config = `server < host: "localhost", port: 8080; >
+users { name: "alice" roles: ["admin", "dev"] limits { cpu: 2 memory: "4Gi" } }
+[example.ext] { enabled: true }`
+
+func TestFormat(t *testing.T) {
+	tests := []struct {
+		width    int
+		expected string
+	}{
+		{80, `server { host: "localhost" port: 8080 }
+users { name: "alice" roles: ["admin", "dev"] limits { cpu: 2 memory: "4Gi" } }
+[example.ext] { enabled: true }`},
+		{40, `server { host: "localhost" port: 8080 }
+users {
+  name: "alice"
+  roles: ["admin", "dev"]
+  limits { cpu: 2 memory: "4Gi" }
+}
+[example.ext] { enabled: true }`},
+		{20, `server {
+  host: "localhost"
+  port: 8080
+}
+users {
+  name: "alice"
+  roles: [
+    "admin",
+    "dev"
+  ]
+  limits {
+    cpu: 2
+    memory: "4Gi"
+  }
+}
+[example.ext] {
+  enabled: true
+}`},
+	}
+	for _, tt := range tests {
+		if actual := format(t, config, tt.width, Options{}); actual != tt.expected {
+			t.Errorf("expected: %v, actual: %v", tt.expected, actual)
+		}
+	}
+}
+
+func TestFormatIdempotent(t *testing.T) {
+	for _, width := range []int{20, 40, 80} {
+		once := format(t, config, width, Options{Align: true})
+		if twice := format(t, once, width, Options{Align: true}); twice != once {
+			t.Errorf("expected: %v, actual: %v", once, twice)
+		}
+	}
+}
+
+func TestFormatAlign(t *testing.T) {
+	src := `user { name: "alice" id: 1 profile { age: 30 } display_name: "Alice"
+  nick: "al"
+
+  nickname: "al" }`
+	expected := `user {
+    name: "alice"
+    id:   1
+    profile { age: 30 }
+    display_name: "Alice"
+    nick:         "al"
+
+    nickname: "al"
+}`
+	if actual := format(t, src, 30, Options{Indent: 4, Align: true}); actual != expected {
+		t.Errorf("expected: %v, actual: %v", expected, actual)
+	}
+	// the values aren't padded when the message fits on one line.
+	expected = `user { name: "alice" id: 1 }`
+	if actual := format(t, `user { name: "alice" id: 1 }`, 80, Options{Align: true}); actual != expected {
+		t.Errorf("expected: %v, actual: %v", expected, actual)
+	}
+}
+
+func TestFormatValues(t *testing.T) {
+	tests := []struct {
+		src      string
+		expected string
+	}{
+		{"a: - 1 b: -inf c: 0x1F d: 1.5e+3f", "a: -1\nb: -inf\nc: 0x1F\nd: 1.5e+3f"},
+		{`s: "abc" 'def'`, `s: "abc" 'def'`},
+		{"m: {} m <> l: [] n: [{ a: 1 }, { a: 2 }]", "m {}\nm {}\nl: []\nn: [{ a: 1 }, { a: 2 }]"},
+		{"a: 1, b: 2; [type.googleapis.com/x.Y] { c: 3 }", "a: 1\nb: 2\n[type.googleapis.com/x.Y] { c: 3 }"},
+	}
+	for _, tt := range tests {
+		if actual := format(t, tt.src, 80, Options{}); actual != tt.expected {
+			t.Errorf("expected: %v, actual: %v", tt.expected, actual)
+		}
+	}
+}
+
+func TestFormatStrings(t *testing.T) {
+	src := `description: "The quick brown fox " "jumps over " "the lazy dog."`
+	expected := `description: "The quick brown fox "
+  "jumps over " "the lazy dog."`
+	if actual := format(t, src, 40, Options{}); actual != expected {
+		t.Errorf("expected: %v, actual: %v", expected, actual)
+	}
+}
+
+func TestFormatComments(t *testing.T) {
+	src := `# the server
+server { host: "localhost" # the host
+
+
+  # the port
+  port: 8080 }
+ports: [80, # http
+  443]
+# the end`
+	expected := `# the server
+server {
+  host: "localhost" # the host
+
+  # the port
+  port: 8080
+}
+ports: [
+  80, # http
+  443
+]
+# the end`
+	if actual := format(t, src, 80, Options{}); actual != expected {
+		t.Errorf("expected: %v, actual: %v", expected, actual)
+	}
+}
+
+func TestFormatError(t *testing.T) {
+	tests := []struct {
+		src      string
+		expected string
+	}{
+		{"a { b: 1", `protobuf: expected "}" at the end`},
+		{"a 1", `protobuf: 1:3: expected ":", found "1"`},
+		{"a: [1 2]", `protobuf: 1:7: expected ",", found "2"`},
+		{"a: }", `protobuf: 1:4: unexpected "}"`},
+		{"a: 'x", "protobuf: 1:6: unterminated string"},
+	}
+	for _, tt := range tests {
+		_, err := Format(tt.src, 80, Options{})
+		if err == nil || err.Error() != tt.expected {
+			t.Errorf("expected: %v, actual: %v", tt.expected, err)
+		}
+	}
+}
+
+func ExampleFormat() {
+	src := `job < name: "build" env { key: "GOOS" value: "linux" } env { key: "GOARCH" value: "amd64" } >`
+	out, err := Format(src, 40, Options{})
+	if err != nil {
+		panic(err)
+	}
+	fmt.Println(out)
+	// Output:
+	// job {
+	//   name: "build"
+	//   env { key: "GOOS" value: "linux" }
+	//   env { key: "GOARCH" value: "amd64" }
+	// }
+}
@@ -0,0 +1,379 @@
+package protobuf
+
+import (
+	"strings"
+
+	p "github.com/tanishiking/prettier"
+)
+
+// block is the statements of a .proto file or in the braces of a message,
+// an enum, a service or another block.
+type block struct {
+	stmts []*statement
+	// end is the comments after the last statement.
+	end []Token
+}
+
+// statement is a statement of a .proto file, which ends with a semicolon
+// or a block.
+type statement struct {
+	items []item
+	// body is the statements in the braces, which is nil unless the
+	// statement ends with a block, and open is the comments after the
+	// opening brace on its line.
+	body *block
+	open []Token
+	// lead is the comments on the lines before the statement, and trail is
+	// the comments after the statement on its last line.
+	lead  []Token
+	trail []Token
+	// line is the first line of the statement, and blank is whether
+	// a blank line is before the statement and its comments.
+	line  int
+	blank bool
+	// endLine is the last line of the statement.
+	endLine int
+}
+
+// item is a token, a message value of an option in the text format, or
+// a list of field options in brackets.
+type item struct {
+	tok     Token
+	message *message
+	options [][]item
+}
+
+// block parses the statements until the closing brace, or until the end
+// if close is empty.
+func (ps *parser) block(close string) (*block, error) {
+	b := &block{}
+	var prev *statement
+	end := ps.line
+	lead := []Token{}
+	for {
+		if prev != nil {
+			prev.trail = ps.trail()
+			prev.endLine = ps.line
+			end = ps.line
+		}
+		lead = append(lead, ps.lead()...)
+		if tok := ps.peek(); tok == nil || close != "" && ps.at(close) {
+			if tok == nil && close != "" {
+				return nil, ps.errorf("expected %q", close)
+			}
+			b.end = lead
+			return b, nil
+		}
+		if ps.at(";") {
+			// an empty statement.
+			ps.next()
+			prev = nil
+			continue
+		}
+		s, err := ps.statement()
+		if err != nil {
+			return nil, err
+		}
+		s.lead = lead
+		first := s.line
+		if len(lead) > 0 {
+			first = lead[0].Line
+		}
+		s.blank = len(b.stmts) > 0 && first-end > 1
+		b.stmts = append(b.stmts, s)
+		prev = s
+		lead = []Token{}
+	}
+}
+
+// statement parses a statement until the semicolon or the end of the block.
+func (ps *parser) statement() (*statement, error) {
+	s := &statement{line: ps.peek().Line}
+	items, err := ps.items(";")
+	if err != nil {
+		return nil, err
+	}
+	s.items = items
+	if len(items) == 0 {
+		return nil, ps.errorf("unexpected %q", ps.peek().Text)
+	}
+	if ps.at(";") {
+		ps.next()
+		return s, nil
+	}
+	// the block of a message, an enum, a service or another statement.
+	ps.next()
+	s.open = ps.trail()
+	if s.body, err = ps.block("}"); err != nil {
+		return nil, err
+	}
+	if err := ps.expect("}"); err != nil {
+		return nil, err
+	}
+	if ps.at(";") {
+		ps.next()
+	}
+	return s, nil
+}
+
+// items parses the items until the stops or the opening brace of a block.
+// The braces following = or : are a message value, and the brackets are
+// a list of field options.
+func (ps *parser) items(stops ...string) ([]item, error) {
+	items := []item{}
+	for {
+		tok := ps.peek()
+		if tok == nil {
+			return nil, ps.errorf("expected %q", stops[0])
+		}
+		for _, stop := range stops {
+			if ps.at(stop) {
+				return items, nil
+			}
+		}
+		switch {
+		case ps.at("{") && len(items) > 0 && (isPunct(items[len(items)-1], "=") || isPunct(items[len(items)-1], ":")):
+			ps.next()
+			m, err := ps.message("}")
+			if err != nil {
+				return nil, err
+			}
+			if err := ps.expect("}"); err != nil {
+				return nil, err
+			}
+			items = append(items, item{message: m})
+		case ps.at("{"):
+			return items, nil
+		case ps.at("}"):
+			return nil, ps.errorf("unexpected %q", "}")
+		case ps.at("["):
+			ps.next()
+			options := [][]item{}
+			for !ps.at("]") {
+				option, err := ps.items(",", "]")
+				if err != nil {
+					return nil, err
+				}
+				options = append(options, option)
+				if ps.at(",") {
+					ps.next()
+				}
+			}
+			ps.next()
+			items = append(items, item{options: options})
+		default:
+			items = append(items, item{tok: ps.next()})
+		}
+	}
+}
+
+func isPunct(it item, punct string) bool {
+	return it.tok.Kind == KindPunct && it.tok.Text == punct
+}
+
+// statements lays out the statements of the file on separate lines.
+func (l *layout) statements(b *block) p.Doc {
+	if len(b.stmts) == 0 && len(b.end) > 0 {
+		return p.Concat([]p.Doc{comment(b.end[0]), l.end(b.end[1:], b.end[0].Line)})
+	}
+	return p.Concat([]p.Doc{l.body(b), l.end(b.end, l.last(b))})
+}
+
+// body lays out the statements on separate lines. The lines except for the
+// first one start with a line break.
+func (l *layout) body(b *block) p.Doc {
+	pads := l.equals(b.stmts)
+	ds := []p.Doc{}
+	for i, s := range b.stmts {
+		if i > 0 {
+			ds = append(ds, p.LineBreak())
+			if s.blank {
+				ds = append(ds, p.LineBreak())
+			}
+		}
+		ds = append(ds, l.lead(s.lead, s.line), l.statement(s, pads[i]), l.trail(s.trail))
+	}
+	return p.Concat(ds)
+}
+
+// last is the last line of the statements of the block, or zero if it's
+// empty.
+func (l *layout) last(b *block) int {
+	if len(b.stmts) == 0 {
+		return 0
+	}
+	return b.stmts[len(b.stmts)-1].endLine
+}
+
+// statement lays out the statement, whose = is preceded by pad spaces.
+func (l *layout) statement(s *statement, pad int) p.Doc {
+	ds := []p.Doc{l.items(s.items, pad)}
+	switch {
+	case s.body == nil:
+		ds = append(ds, p.Text(";"))
+	case len(s.body.stmts) == 0 && len(s.body.end) == 0 && len(s.open) == 0:
+		ds = append(ds, p.Text(" {}"))
+	default:
+		body := p.Concat([]p.Doc{
+			p.LineBreak(),
+			l.body(s.body),
+			l.end(s.body.end, l.last(s.body)),
+		})
+		if len(s.body.stmts) == 0 {
+			body = l.end(s.body.end, 0)
+		}
+		ds = append(ds, p.Text(" {"), l.trail(s.open), p.Nest(l.opts.Indent, body), p.LineBreak(), p.Text("}"))
+	}
+	return p.Concat(ds)
+}
+
+// equals is the numbers of the spaces before = of the statements to align
+// the numbers of consecutive fields and enum values.
+func (l *layout) equals(stmts []*statement) []int {
+	pads := make([]int, len(stmts))
+	if !l.opts.Align {
+		return pads
+	}
+	widths := make([]int, len(stmts))
+	for i, s := range stmts {
+		widths[i] = -1
+		if s.body == nil && !isIdent(s.items[0], "option") {
+			if prefix, ok := l.prefix(s.items); ok {
+				widths[i] = len(prefix)
+			}
+		}
+	}
+	for start := 0; start < len(stmts); {
+		if widths[start] < 0 {
+			start++
+			continue
+		}
+		end := start + 1
+		for end < len(stmts) && widths[end] >= 0 && !stmts[end].blank {
+			end++
+		}
+		width := 0
+		for _, w := range widths[start:end] {
+			if w > width {
+				width = w
+			}
+		}
+		for i := start; i < end; i++ {
+			pads[i] = width - widths[i]
+		}
+		start = end
+	}
+	return pads
+}
+
+func isIdent(it item, ident string) bool {
+	return it.tok.Kind == KindIdent && it.tok.Text == ident
+}
+
+// prefix is the text of the tokens before =, which is false if the items
+// have no = or other items than tokens are before it.
+func (l *layout) prefix(items []item) (string, bool) {
+	var b strings.Builder
+	for i, it := range items {
+		if isPunct(it, "=") {
+			return b.String(), i > 0
+		}
+		if it.message != nil || it.options != nil || it.tok.Kind == KindComment {
+			return "", false
+		}
+		if i > 0 && spaced(items, i) {
+			b.WriteString(" ")
+		}
+		b.WriteString(it.tok.Text)
+	}
+	return "", false
+}
+
+// items lays out the items separated by the spaces, where the first = is
+// preceded by pad spaces. The items following a line comment start on the
+// next line.
+func (l *layout) items(items []item, pad int) p.Doc {
+	ds := []p.Doc{}
+	padded := false
+	for i, it := range items {
+		switch {
+		case i == 0 || isLineComment(items[i-1]):
+		case isPunct(it, "=") && !padded:
+			padded = true
+			ds = append(ds, p.Spaces(uint(pad)+1))
+		case spaced(items, i):
+			ds = append(ds, p.Text(" "))
+		}
+		switch {
+		case it.message != nil:
+			ds = append(ds, l.fields(it.message, false))
+		case it.options != nil:
+			ds = append(ds, l.options(it.options))
+		case it.tok.Kind == KindComment:
+			ds = append(ds, comment(it.tok))
+			if it.tok.isLineComment() {
+				ds = append(ds, p.LineBreak())
+			}
+		default:
+			ds = append(ds, p.Text(it.tok.Text))
+		}
+	}
+	return p.Concat(ds)
+}
+
+func isLineComment(it item) bool {
+	return it.message == nil && it.options == nil && it.tok.isLineComment()
+}
+
+// options lays out the field options in brackets, on one line if they fit.
+func (l *layout) options(options [][]item) p.Doc {
+	hard := false
+	ds := make([]p.Doc, len(options))
+	for i, option := range options {
+		ds[i] = l.items(option, 0)
+		for _, it := range option {
+			hard = hard || it.tok.Kind == KindComment && it.message == nil && it.options == nil || it.message != nil && it.message.hard
+		}
+	}
+	if !hard {
+		body := p.Intercalate(p.Concat([]p.Doc{p.Text(","), p.Line()}), ds)
+		return p.TightBracketBy(p.Text("["), p.Text("]"), body, l.opts.Indent)
+	}
+	body := p.Intercalate(p.Concat([]p.Doc{p.Text(","), p.LineBreak()}), ds)
+	return p.Concat([]p.Doc{
+		p.Text("["),
+		p.Nest(l.opts.Indent, p.Concat([]p.Doc{p.LineBreak(), body})),
+		p.LineBreak(),
+		p.Text("]"),
+	})
+}
+
+// spaced reports whether a space is between the item and the previous
+// one. The dots of full names and the slashes of type URLs are kept as
+// they are written.
+func spaced(items []item, i int) bool {
+	prev, cur := items[i-1], items[i]
+	if cur.message != nil || cur.options != nil {
+		return true
+	}
+	if prev.message != nil || prev.options != nil {
+		return !isPunct(cur, ";") && !isPunct(cur, ",")
+	}
+	switch {
+	case isPunct(cur, ".") || isPunct(prev, ".") || isPunct(cur, "/") || isPunct(prev, "/"):
+		return cur.tok.Line != prev.tok.Line || cur.tok.Col > prev.tok.end()
+	case cur.tok.Kind == KindPunct && strings.Contains(";,)]>", cur.tok.Text):
+		return false
+	case prev.tok.Kind == KindPunct && strings.Contains("([<", prev.tok.Text):
+		return false
+	case isPunct(cur, "<"):
+		return !isIdent(prev, "map")
+	case isPunct(cur, "("):
+		// the name of a method.
+		return !(i >= 2 && isIdent(items[i-2], "rpc"))
+	case isPunct(prev, "-") || isPunct(prev, "+"):
+		// the sign of a number.
+		return i >= 2 && !isPunct(items[i-2], "=") && !isPunct(items[i-2], ",") && !isPunct(items[i-2], "(") && !isPunct(items[i-2], ":")
+	}
+	return true
+}
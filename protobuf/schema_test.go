@@ -0,0 +1,214 @@
+package protobuf
+
+import (
+	"fmt"
+	"testing"
+)
+
+func formatSchema(t *testing.T, src string, width int, opts Options) string {
+	t.Helper()
+	actual, err := FormatSchema(src, width, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return actual
+}
+
+const schema = `syntax="proto3";
+package acme.v1;
+import "google/protobuf/timestamp.proto";
+option go_package="acme/v1;acmev1";
+
+message User {
+  string name=1;
+  repeated string roles = 2 [deprecated=true, json_name="roles"];
+  map<string,int64> quotas=3;
+  google.protobuf.Timestamp created_at = 4 [(validate.rules).timestamp = {required: true}];
+  oneof contact { string email = 5; string phone = 6; }
+  reserved 7, 9 to 11;
+  enum State { STATE_UNSPECIFIED = 0; STATE_ACTIVE=1; }
+  message Empty {}
+}
+
+service Users {
+  rpc Get ( GetRequest ) returns ( User );
+  rpc Watch(WatchRequest) returns (stream User) { option (google.api.http) = { get: "/v1/users" }; }
+}`
+
+func TestFormatSchema(t *testing.T) {
+	tests := []struct {
+		width    int
+		expected string
+	}{
+		{100, `syntax = "proto3";
+package acme.v1;
+import "google/protobuf/timestamp.proto";
+option go_package = "acme/v1;acmev1";
+
+message User {
+  string name = 1;
+  repeated string roles = 2 [deprecated = true, json_name = "roles"];
+  map<string, int64> quotas = 3;
+  google.protobuf.Timestamp created_at = 4 [(validate.rules).timestamp = { required: true }];
+  oneof contact {
+    string email = 5;
+    string phone = 6;
+  }
+  reserved 7, 9 to 11;
+  enum State {
+    STATE_UNSPECIFIED = 0;
+    STATE_ACTIVE = 1;
+  }
+  message Empty {}
+}
+
+service Users {
+  rpc Get(GetRequest) returns (User);
+  rpc Watch(WatchRequest) returns (stream User) {
+    option (google.api.http) = { get: "/v1/users" };
+  }
+}`},
+		{50, `syntax = "proto3";
+package acme.v1;
+import "google/protobuf/timestamp.proto";
+option go_package = "acme/v1;acmev1";
+
+message User {
+  string name = 1;
+  repeated string roles = 2 [
+    deprecated = true,
+    json_name = "roles"
+  ];
+  map<string, int64> quotas = 3;
+  google.protobuf.Timestamp created_at = 4 [
+    (validate.rules).timestamp = {
+      required: true
+    }
+  ];
+  oneof contact {
+    string email = 5;
+    string phone = 6;
+  }
+  reserved 7, 9 to 11;
+  enum State {
+    STATE_UNSPECIFIED = 0;
+    STATE_ACTIVE = 1;
+  }
+  message Empty {}
+}
+
+service Users {
+  rpc Get(GetRequest) returns (User);
+  rpc Watch(WatchRequest) returns (stream User) {
+    option (google.api.http) = {
+      get: "/v1/users"
+    };
+  }
+}`},
+	}
+	for _, tt := range tests {
+		if actual := formatSchema(t, schema, tt.width, Options{}); actual != tt.expected {
+			t.Errorf("expected: %v, actual: %v", tt.expected, actual)
+		}
+	}
+}
+
+func TestFormatSchemaIdempotent(t *testing.T) {
+	for _, width := range []int{20, 50, 100} {
+		once := formatSchema(t, schema, width, Options{Align: true})
+		if twice := formatSchema(t, once, width, Options{Align: true}); twice != once {
+			t.Errorf("expected: %v, actual: %v", once, twice)
+		}
+	}
+}
+
+func TestFormatSchemaAlign(t *testing.T) {
+	src := `message Point {
+  int32 x = 1;
+  int32 y = 2;
+  optional string label = 3;
+  option deprecated = true;
+  repeated Point neighbors = 4;
+
+  bool visible = 5;
+}`
+	expected := `message Point {
+  int32 x               = 1;
+  int32 y               = 2;
+  optional string label = 3;
+  option deprecated = true;
+  repeated Point neighbors = 4;
+
+  bool visible = 5;
+}`
+	if actual := formatSchema(t, src, 80, Options{Align: true}); actual != expected {
+		t.Errorf("expected: %v, actual: %v", expected, actual)
+	}
+}
+
+func TestFormatSchemaComments(t *testing.T) {
+	src := `/* The file.
+   Second line. */
+syntax = "proto2";
+// A message.
+message A { // open
+  optional int32 x = 1 [default = -5, /* why */ deprecated = true];
+  optional string y = 2; // trailing
+
+
+  // end
+}
+enum E { // open
+}`
+	expected := `/* The file.
+   Second line. */
+syntax = "proto2";
+// A message.
+message A { // open
+  optional int32 x = 1 [
+    default = -5,
+    /* why */ deprecated = true
+  ];
+  optional string y = 2; // trailing
+
+  // end
+}
+enum E { // open
+}`
+	if actual := formatSchema(t, src, 80, Options{}); actual != expected {
+		t.Errorf("expected: %v, actual: %v", expected, actual)
+	}
+}
+
+func TestFormatSchemaError(t *testing.T) {
+	tests := []struct {
+		src      string
+		expected string
+	}{
+		{"message A {", `protobuf: expected "}" at the end`},
+		{"message A {}}", `protobuf: 1:13: unexpected "}"`},
+		{"syntax = \"proto3\"", `protobuf: expected ";" at the end`},
+		{"option (a) = { b 1 };", `protobuf: 1:18: expected ":", found "1"`},
+	}
+	for _, tt := range tests {
+		_, err := FormatSchema(tt.src, 80, Options{})
+		if err == nil || err.Error() != tt.expected {
+			t.Errorf("expected: %v, actual: %v", tt.expected, err)
+		}
+	}
+}
+
+func ExampleFormatSchema() {
+	src := `enum Color{COLOR_UNSPECIFIED=0;RED=1;GREEN=2;}`
+	out, err := FormatSchema(src, 80, Options{Align: true})
+	if err != nil {
+		panic(err)
+	}
+	fmt.Println(out)
+	// Output:
+	// enum Color {
+	//   COLOR_UNSPECIFIED = 0;
+	//   RED               = 1;
+	//   GREEN             = 2;
+	// }
+}
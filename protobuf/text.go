@@ -0,0 +1,360 @@
+package protobuf
+
+import (
+	"strings"
+
+	p "github.com/tanishiking/prettier"
+)
+
+// message is a message of the text format.
+type message struct {
+	fields []*field
+	// end is the comments after the last field.
+	end []Token
+	// hard is whether the message is always broken into lines since it has
+	// comments.
+	hard bool
+}
+
+// field is a field of a message, or an element of a list whose name is
+// empty.
+type field struct {
+	name string
+	// scalar is the tokens of a scalar value, such as a number with its
+	// sign or adjacent strings.
+	scalar  []string
+	message *message
+	list    *list
+	// lead is the comments on the lines before the field, and trail is the
+	// comments after the field on its last line.
+	lead  []Token
+	trail []Token
+	// line is the first line of the field, and blank is whether a blank
+	// line is before the field and its comments.
+	line  int
+	blank bool
+	// endLine is the last line of the field.
+	endLine int
+}
+
+// list is a list of the scalar values or the messages of a repeated
+// field.
+type list struct {
+	elems []*field
+	end   []Token
+	hard  bool
+}
+
+// message parses the fields until the closing bracket, or until the end
+// if close is empty.
+func (ps *parser) message(close string) (*message, error) {
+	m := &message{}
+	var prev *field
+	end := ps.line
+	for {
+		if prev != nil {
+			prev.trail = ps.trail()
+			prev.endLine = ps.line
+			end = ps.line
+			m.hard = m.hard || len(prev.trail) > 0
+		}
+		lead := ps.lead()
+		if tok := ps.peek(); tok == nil || close != "" && ps.at(close) {
+			if tok == nil && close != "" {
+				return nil, ps.errorf("expected %q", close)
+			}
+			m.end = lead
+			m.hard = m.hard || len(lead) > 0
+			return m, nil
+		}
+		f, err := ps.field()
+		if err != nil {
+			return nil, err
+		}
+		f.lead = lead
+		first := f.line
+		if len(lead) > 0 {
+			first = lead[0].Line
+		}
+		f.blank = prev != nil && first-end > 1
+		m.fields = append(m.fields, f)
+		m.hard = m.hard || len(lead) > 0 || f.hard()
+		prev = f
+	}
+}
+
+// field parses a field with its name.
+func (ps *parser) field() (*field, error) {
+	tok := ps.peek()
+	f := &field{line: tok.Line}
+	switch {
+	case tok.Kind == KindIdent:
+		f.name = ps.next().Text
+	case ps.at("["):
+		// an extension or the type URL of an Any.
+		var b strings.Builder
+		ps.next()
+		for !ps.at("]") {
+			if ps.peek() == nil || ps.peek().Kind == KindComment {
+				return nil, ps.errorf("expected %q", "]")
+			}
+			b.WriteString(ps.next().Text)
+		}
+		ps.next()
+		f.name = "[" + b.String() + "]"
+	default:
+		return nil, ps.errorf("unexpected %q", tok.Text)
+	}
+	colon := ps.at(":")
+	if colon {
+		ps.next()
+	}
+	if err := ps.value(f, colon); err != nil {
+		return nil, err
+	}
+	if ps.at(",") || ps.at(";") {
+		ps.next()
+	}
+	return f, nil
+}
+
+// value parses the value of the field. A scalar value must follow a colon.
+func (ps *parser) value(f *field, colon bool) error {
+	var err error
+	switch {
+	case ps.at("{"):
+		ps.next()
+		f.message, err = ps.message("}")
+		if err == nil {
+			err = ps.expect("}")
+		}
+		return err
+	case ps.at("<"):
+		ps.next()
+		f.message, err = ps.message(">")
+		if err == nil {
+			err = ps.expect(">")
+		}
+		return err
+	case ps.at("["):
+		ps.next()
+		f.list, err = ps.list()
+		return err
+	case !colon:
+		if ps.peek() == nil {
+			return ps.errorf("expected %q", ":")
+		}
+		return ps.errorf("expected %q, found %q", ":", ps.peek().Text)
+	}
+	tok := ps.peek()
+	switch {
+	case tok == nil:
+		return ps.errorf("expected a value")
+	case ps.at("-") || ps.at("+"):
+		sign := ps.next().Text
+		if tok := ps.peek(); tok == nil || tok.Kind != KindNumber && tok.Kind != KindIdent {
+			return ps.errorf("expected a number")
+		}
+		f.scalar = []string{sign + ps.next().Text}
+	case tok.Kind == KindString:
+		for tok := ps.peek(); tok != nil && tok.Kind == KindString; tok = ps.peek() {
+			f.scalar = append(f.scalar, ps.next().Text)
+		}
+	case tok.Kind == KindNumber || tok.Kind == KindIdent:
+		f.scalar = []string{ps.next().Text}
+	default:
+		return ps.errorf("unexpected %q", tok.Text)
+	}
+	return nil
+}
+
+// list parses the elements of a list until the closing bracket.
+func (ps *parser) list() (*list, error) {
+	ls := &list{}
+	for {
+		lead := ps.lead()
+		if ps.at("]") {
+			ps.next()
+			ls.end = lead
+			ls.hard = ls.hard || len(lead) > 0
+			return ls, nil
+		}
+		if ps.peek() == nil {
+			return nil, ps.errorf("expected %q", "]")
+		}
+		e := &field{line: ps.peek().Line, lead: lead}
+		if err := ps.value(e, true); err != nil {
+			return nil, err
+		}
+		e.trail = ps.trail()
+		if !ps.closes("]") {
+			if err := ps.expect(","); err != nil {
+				return nil, err
+			}
+			e.trail = append(e.trail, ps.trail()...)
+		}
+		e.endLine = ps.line
+		ls.elems = append(ls.elems, e)
+		ls.hard = ls.hard || len(lead) > 0 || len(e.trail) > 0 || e.hard()
+	}
+}
+
+// closes reports whether the next token except for comments is the
+// closing bracket.
+func (ps *parser) closes(close string) bool {
+	for _, tok := range ps.toks[ps.pos:] {
+		if tok.Kind != KindComment {
+			return tok.Kind == KindPunct && tok.Text == close
+		}
+	}
+	return false
+}
+
+// hard reports whether the value of the field is always broken into lines.
+func (f *field) hard() bool {
+	return f.message != nil && f.message.hard || f.list != nil && f.list.hard
+}
+
+// fields lays out the fields of the message on separate lines if top is
+// true, and otherwise in braces.
+func (l *layout) fields(m *message, top bool) p.Doc {
+	if !top && len(m.fields) == 0 && len(m.end) == 0 {
+		return p.Text("{}")
+	}
+	hard := top || m.hard
+	sep := p.Line()
+	if hard {
+		sep = p.LineBreak()
+	}
+	pads := l.pads(m.fields)
+	ds := []p.Doc{}
+	last := 0
+	for i, f := range m.fields {
+		if i > 0 {
+			if f.blank {
+				ds = append(ds, p.IfBreak(p.LineBreak(), p.Empty()))
+			}
+			ds = append(ds, sep)
+		}
+		ds = append(ds, l.lead(f.lead, f.line), l.field(f, pads[i]), l.trail(f.trail))
+		last = f.endLine
+	}
+	end := l.end(m.end, last)
+	if top {
+		if len(m.fields) == 0 && len(m.end) > 0 {
+			// the comments start with a line break.
+			end = l.end(m.end[1:], m.end[0].Line)
+			return p.Concat([]p.Doc{comment(m.end[0]), end})
+		}
+		return p.Concat([]p.Doc{p.Concat(ds), end})
+	}
+	body := p.Concat([]p.Doc{sep, p.Concat(ds), end})
+	doc := p.Concat([]p.Doc{p.Text("{"), p.Nest(l.opts.Indent, body), sep, p.Text("}")})
+	if hard {
+		return doc
+	}
+	return p.Group(doc)
+}
+
+// pads is the numbers of the spaces after the names of the fields to align
+// their values. The values of consecutive fields which are not messages
+// are aligned unless a blank line is between them.
+func (l *layout) pads(fields []*field) []int {
+	pads := make([]int, len(fields))
+	if !l.opts.Align {
+		return pads
+	}
+	for start := 0; start < len(fields); {
+		if fields[start].message != nil {
+			start++
+			continue
+		}
+		end := start + 1
+		for end < len(fields) && fields[end].message == nil && !fields[end].blank {
+			end++
+		}
+		width := 0
+		for _, f := range fields[start:end] {
+			if len(f.name) > width {
+				width = len(f.name)
+			}
+		}
+		for i := start; i < end; i++ {
+			pads[i] = width - len(fields[i].name)
+		}
+		start = end
+	}
+	return pads
+}
+
+// field lays out the name and the value of the field. The values of
+// a field on its own line are aligned by padding the name with spaces.
+func (l *layout) field(f *field, pad int) p.Doc {
+	if f.message != nil && f.name == "" {
+		return l.fields(f.message, false)
+	}
+	if f.message != nil {
+		return p.Concat([]p.Doc{p.Text(f.name + " "), l.fields(f.message, false)})
+	}
+	ds := []p.Doc{}
+	if f.name != "" {
+		ds = append(ds, p.Text(f.name+":"))
+		if pad > 0 {
+			ds = append(ds, p.IfBreak(p.Spaces(uint(pad)), p.Empty()))
+		}
+		ds = append(ds, p.Text(" "))
+	}
+	if f.list != nil {
+		ds = append(ds, l.list(f.list))
+	} else {
+		ds = append(ds, l.scalar(f.scalar))
+	}
+	return p.Concat(ds)
+}
+
+// scalar lays out the scalar value. Adjacent strings are filled in lines.
+func (l *layout) scalar(tokens []string) p.Doc {
+	if len(tokens) == 1 {
+		return p.Text(tokens[0])
+	}
+	ds := make([]p.Doc, len(tokens))
+	for i, t := range tokens {
+		ds[i] = p.Text(t)
+	}
+	return p.Nest(l.opts.Indent, p.Fill(p.Line(), ds))
+}
+
+// list lays out the elements of the list in brackets, on one line if they
+// fit.
+func (l *layout) list(ls *list) p.Doc {
+	if len(ls.elems) == 0 && len(ls.end) == 0 {
+		return p.Text("[]")
+	}
+	ds := []p.Doc{}
+	last := 0
+	for i, e := range ls.elems {
+		if i > 0 {
+			if ls.hard {
+				ds = append(ds, p.LineBreak())
+			} else {
+				ds = append(ds, p.Line())
+			}
+		}
+		comma := p.Text(",")
+		if i == len(ls.elems)-1 {
+			comma = p.Empty()
+		}
+		ds = append(ds, l.lead(e.lead, e.line), l.field(e, 0), comma, l.trail(e.trail))
+		last = e.endLine
+	}
+	ds = append(ds, l.end(ls.end, last))
+	if !ls.hard {
+		return p.TightBracketBy(p.Text("["), p.Text("]"), p.Concat(ds), l.opts.Indent)
+	}
+	return p.Concat([]p.Doc{
+		p.Text("["),
+		p.Nest(l.opts.Indent, p.Concat([]p.Doc{p.LineBreak(), p.Concat(ds)})),
+		p.LineBreak(),
+		p.Text("]"),
+	})
+}
@@ -0,0 +1,199 @@
+package protobuf
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// Kind is the kind of a Token.
+type Kind int
+
+// Kinds of Token.
+const (
+	KindIdent Kind = iota
+	KindNumber
+	KindString
+	KindPunct
+	KindComment
+)
+
+// Token is a token of the text format or of a .proto file.
+type Token struct {
+	Kind Kind
+	// Text is the text of the token as it is written, including the quotes
+	// of strings and the delimiters of comments.
+	Text string
+	// Line and Col are the 1-based position of the token.
+	Line int
+	Col  int
+}
+
+// end is the column after the token, which is on one line unless it's a
+// block comment.
+func (t Token) end() int {
+	return t.Col + utf8.RuneCountInString(t.Text)
+}
+
+// isLineComment reports whether the token is a comment which runs until
+// the end of the line.
+func (t Token) isLineComment() bool {
+	return t.Kind == KindComment && !strings.HasPrefix(t.Text, "/*")
+}
+
+// lines is the number of the lines the token spans.
+func (t Token) lines() int {
+	return strings.Count(t.Text, "\n") + 1
+}
+
+// Tokenize splits the text format or the .proto file into tokens. Spaces
+// are skipped, and comments starting with #, // or /* are kept as tokens.
+func Tokenize(src string) ([]Token, error) {
+	s := &scanner{src: src, line: 1, col: 1}
+	toks := []Token{}
+	for {
+		s.skipSpaces()
+		if s.pos >= len(s.src) {
+			return toks, nil
+		}
+		tok, err := s.token()
+		if err != nil {
+			return nil, err
+		}
+		toks = append(toks, tok)
+	}
+}
+
+type scanner struct {
+	src  string
+	pos  int
+	line int
+	col  int
+}
+
+func (s *scanner) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("protobuf: %d:%d: %s", s.line, s.col, fmt.Sprintf(format, args...))
+}
+
+func (s *scanner) peek(i int) byte {
+	if s.pos+i < len(s.src) {
+		return s.src[s.pos+i]
+	}
+	return 0
+}
+
+func (s *scanner) advance(n int) {
+	for _, c := range s.src[s.pos : s.pos+n] {
+		if c == '\n' {
+			s.line++
+			s.col = 1
+		} else {
+			s.col++
+		}
+	}
+	s.pos += n
+}
+
+func (s *scanner) skipSpaces() {
+	for s.pos < len(s.src) && strings.IndexByte(" \t\r\n\f\v", s.src[s.pos]) >= 0 {
+		s.advance(1)
+	}
+}
+
+func (s *scanner) token() (Token, error) {
+	start, line, col := s.pos, s.line, s.col
+	kind, err := s.scan()
+	if err != nil {
+		return Token{}, err
+	}
+	return Token{Kind: kind, Text: s.src[start:s.pos], Line: line, Col: col}, nil
+}
+
+// scan advances the scanner over the next token, and returns its kind.
+func (s *scanner) scan() (Kind, error) {
+	c := s.src[s.pos]
+	switch {
+	case c == '#' || c == '/' && s.peek(1) == '/':
+		n := strings.IndexByte(s.src[s.pos:], '\n')
+		if n < 0 {
+			n = len(s.src) - s.pos
+		}
+		s.advance(len(strings.TrimRight(s.src[s.pos:s.pos+n], " \t\r")))
+		return KindComment, nil
+	case c == '/' && s.peek(1) == '*':
+		n := strings.Index(s.src[s.pos+2:], "*/")
+		if n < 0 {
+			return 0, s.errorf("unterminated comment")
+		}
+		s.advance(n + 4)
+		return KindComment, nil
+	case c == '"' || c == '\'':
+		return KindString, s.quoted(c)
+	case isDigit(c) || c == '.' && isDigit(s.peek(1)):
+		s.number()
+		return KindNumber, nil
+	case isLetter(c):
+		for s.pos < len(s.src) && (isLetter(s.src[s.pos]) || isDigit(s.src[s.pos])) {
+			s.advance(1)
+		}
+		return KindIdent, nil
+	case strings.IndexByte("{}<>[]():;,=.-+/", c) >= 0:
+		s.advance(1)
+		return KindPunct, nil
+	}
+	r, _ := utf8.DecodeRuneInString(s.src[s.pos:])
+	return 0, s.errorf("unexpected %q", r)
+}
+
+// quoted advances over the string quoted by q, which may have escaped
+// characters but no newlines.
+func (s *scanner) quoted(q byte) error {
+	s.advance(1)
+	for s.pos < len(s.src) {
+		switch s.src[s.pos] {
+		case q:
+			s.advance(1)
+			return nil
+		case '\n':
+			return s.errorf("unterminated string")
+		case '\\':
+			if s.pos+1 < len(s.src) && s.src[s.pos+1] != '\n' {
+				s.advance(1)
+			}
+		}
+		s.advance(1)
+	}
+	return s.errorf("unterminated string")
+}
+
+// number advances over a decimal, octal, hexadecimal or floating-point
+// number with an optional suffix such as f.
+func (s *scanner) number() {
+	for s.pos < len(s.src) {
+		c := s.src[s.pos]
+		if (c == '+' || c == '-') && strings.IndexByte("eE", s.src[s.pos-1]) >= 0 && !isHex(s.src[:s.pos]) {
+			s.advance(1)
+			continue
+		}
+		if !isDigit(c) && !isLetter(c) && c != '.' {
+			return
+		}
+		s.advance(1)
+	}
+}
+
+// isHex reports whether the number scanned so far is hexadecimal, whose
+// e is a digit rather than an exponent.
+func isHex(src string) bool {
+	i := strings.LastIndexAny(src, " \t\r\n\f\v{}<>[]():;,=-+/")
+	num := src[i+1:]
+	return strings.HasPrefix(num, "0x") || strings.HasPrefix(num, "0X")
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isLetter(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c == '_'
+}
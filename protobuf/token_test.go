@@ -0,0 +1,56 @@
+package protobuf
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTokenize(t *testing.T) {
+	toks, err := Tokenize("a: -1.5e-3 # c\nb: 0x1E+ 'x\\'y' /* d\n e */ [x.y/z] {}")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []Token{
+		{Kind: KindIdent, Text: "a", Line: 1, Col: 1},
+		{Kind: KindPunct, Text: ":", Line: 1, Col: 2},
+		{Kind: KindPunct, Text: "-", Line: 1, Col: 4},
+		{Kind: KindNumber, Text: "1.5e-3", Line: 1, Col: 5},
+		{Kind: KindComment, Text: "# c", Line: 1, Col: 12},
+		{Kind: KindIdent, Text: "b", Line: 2, Col: 1},
+		{Kind: KindPunct, Text: ":", Line: 2, Col: 2},
+		{Kind: KindNumber, Text: "0x1E", Line: 2, Col: 4},
+		{Kind: KindPunct, Text: "+", Line: 2, Col: 8},
+		{Kind: KindString, Text: `'x\'y'`, Line: 2, Col: 10},
+		{Kind: KindComment, Text: "/* d\n e */", Line: 2, Col: 17},
+		{Kind: KindPunct, Text: "[", Line: 3, Col: 7},
+		{Kind: KindIdent, Text: "x", Line: 3, Col: 8},
+		{Kind: KindPunct, Text: ".", Line: 3, Col: 9},
+		{Kind: KindIdent, Text: "y", Line: 3, Col: 10},
+		{Kind: KindPunct, Text: "/", Line: 3, Col: 11},
+		{Kind: KindIdent, Text: "z", Line: 3, Col: 12},
+		{Kind: KindPunct, Text: "]", Line: 3, Col: 13},
+		{Kind: KindPunct, Text: "{", Line: 3, Col: 15},
+		{Kind: KindPunct, Text: "}", Line: 3, Col: 16},
+	}
+	if !reflect.DeepEqual(toks, expected) {
+		t.Errorf("expected: %v, actual: %v", expected, toks)
+	}
+}
+
+func TestTokenizeError(t *testing.T) {
+	tests := []struct {
+		src      string
+		expected string
+	}{
+		{"a: 'x", "protobuf: 1:6: unterminated string"},
+		{"a: \"x\ny\"", "protobuf: 1:6: unterminated string"},
+		{"/* a", "protobuf: 1:1: unterminated comment"},
+		{"a: @", "protobuf: 1:4: unexpected '@'"},
+	}
+	for _, tt := range tests {
+		_, err := Tokenize(tt.src)
+		if err == nil || err.Error() != tt.expected {
+			t.Errorf("expected: %v, actual: %v", tt.expected, err)
+		}
+	}
+}
@@ -0,0 +1,102 @@
+package prettier
+
+import (
+	"strings"
+)
+
+// Position is a location in the rendered output.
+// All fields are zero-based, and Column is counted in bytes
+// from the beginning of the line like Offset. SourceMap converts it into
+// UTF-16 code units.
+type Position struct {
+	Offset int
+	Line   int
+	Column int
+}
+
+// Span is the range of the output that an annotated document
+// (see Annotate) is rendered to. End is exclusive.
+type Span struct {
+	Annotation interface{}
+	Start      Position
+	End        Position
+}
+
+// Result is the output of Render.
+type Result struct {
+	// Output is the rendered string, the same as Pretty returns.
+	Output string
+	// Spans contains a span for each annotation in the rendered layout,
+	// ordered by their start positions.
+	Spans []Span
+	// Cursors contains the positions of Cursor markers in the output,
+	// in the order they appear.
+	Cursors []Position
+	// Decisions contains the decisions made for the layout
+	// in the order they appear, only if it is rendered by RenderTrace.
+	Decisions []Decision
+}
+
+// Render renders the given Doc in the same way as Pretty,
+// and additionally reports where each annotation and cursor ended up
+// in the output.
+func Render(width int, doc Doc) *Result {
+	return render(best(width, uint(0), doc))
+}
+
+func render(c chunk) *Result {
+	var sb strings.Builder
+	var pos Position
+	spans := []Span{}
+	cursors := []Position{}
+	var decisions []Decision
+	// indices of spans whose end has not been found yet.
+	open := []int{}
+	for {
+		switch v := c.(type) {
+		case *textChunk:
+			sb.WriteString(v.str)
+			pos.Offset += len(v.str)
+			pos.Column += len(v.str)
+			c = v.c
+		case *lineChunk:
+			sb.WriteString("\n")
+			sb.WriteString(strings.Repeat(" ", int(v.indent)))
+			pos.Offset += 1 + int(v.indent)
+			pos.Line++
+			pos.Column = int(v.indent)
+			c = v.c
+		case *annotationChunk:
+			if v.start {
+				open = append(open, len(spans))
+				spans = append(spans, Span{Annotation: v.value, Start: pos})
+			} else {
+				last := len(open) - 1
+				spans[open[last]].End = pos
+				open = open[:last]
+			}
+			c = v.c
+		case *cursorChunk:
+			cursors = append(cursors, pos)
+			c = v.c
+		case *traceChunk:
+			d := v.decision
+			d.Position = pos
+			d.Annotations = []interface{}{}
+			for _, i := range open {
+				d.Annotations = append(d.Annotations, spans[i].Annotation)
+			}
+			decisions = append(decisions, d)
+			c = v.c
+		case *lazyChunk:
+			c = v.force()
+		default:
+			return &Result{
+				Output:    sb.String(),
+				Spans:     spans,
+				Cursors:   cursors,
+				Decisions: decisions,
+			}
+		}
+	}
+}
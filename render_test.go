@@ -0,0 +1,102 @@
+package prettier
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestRenderSpans(t *testing.T) {
+	sep := Concat([]Doc{Text(","), LineOrSpace()})
+	ds := []Doc{
+		Annotate("foo", Text("foo")),
+		Annotate("bar", Text("bar")),
+		Annotate("baz", Text("baz")),
+	}
+	doc := Annotate("list", TightBracketBy(
+		Text("["),
+		Text("]"),
+		Intercalate(sep, ds),
+		uint(2),
+	))
+
+	flat := Render(40, doc)
+	if flat.Output != "[foo, bar, baz]" {
+		t.Errorf("expected: %v, actual: %v", "[foo, bar, baz]", flat.Output)
+	}
+	expected := []Span{
+		{Annotation: "list", Start: Position{0, 0, 0}, End: Position{15, 0, 15}},
+		{Annotation: "foo", Start: Position{1, 0, 1}, End: Position{4, 0, 4}},
+		{Annotation: "bar", Start: Position{6, 0, 6}, End: Position{9, 0, 9}},
+		{Annotation: "baz", Start: Position{11, 0, 11}, End: Position{14, 0, 14}},
+	}
+	if !reflect.DeepEqual(flat.Spans, expected) {
+		t.Errorf("expected: %v, actual: %v", expected, flat.Spans)
+	}
+
+	broken := Render(5, doc)
+	if broken.Output != "[\n  foo,\n  bar,\n  baz\n]" {
+		t.Errorf("expected: %q, actual: %q", "[\n  foo,\n  bar,\n  baz\n]", broken.Output)
+	}
+	expected = []Span{
+		{Annotation: "list", Start: Position{0, 0, 0}, End: Position{23, 4, 1}},
+		{Annotation: "foo", Start: Position{4, 1, 2}, End: Position{7, 1, 5}},
+		{Annotation: "bar", Start: Position{11, 2, 2}, End: Position{14, 2, 5}},
+		{Annotation: "baz", Start: Position{18, 3, 2}, End: Position{21, 3, 5}},
+	}
+	if !reflect.DeepEqual(broken.Spans, expected) {
+		t.Errorf("expected: %v, actual: %v", expected, broken.Spans)
+	}
+}
+
+func TestRenderWithoutAnnotation(t *testing.T) {
+	doc := Concat([]Doc{Text("a"), Line(), Text("b")})
+	result := Render(80, doc)
+	if result.Output != Pretty(80, doc) {
+		t.Errorf("expected: %v, actual: %v", Pretty(80, doc), result.Output)
+	}
+	if len(result.Spans) != 0 {
+		t.Errorf("expected no spans, actual: %v", result.Spans)
+	}
+}
+
+func TestRenderCursors(t *testing.T) {
+	// selection from "b" to "c"
+	doc := Group(Concat([]Doc{
+		Text("a"),
+		Line(),
+		Cursor(),
+		Text("b"),
+		Line(),
+		Text("c"),
+		Cursor(),
+	}))
+	flat := Render(80, doc)
+	expected := []Position{{2, 0, 2}, {5, 0, 5}}
+	if flat.Output != "a b c" || !reflect.DeepEqual(flat.Cursors, expected) {
+		t.Errorf("expected: %v, actual: %v", expected, flat.Cursors)
+	}
+	broken := Render(2, doc)
+	expected = []Position{{2, 1, 0}, {5, 2, 1}}
+	if broken.Output != "a\nb\nc" || !reflect.DeepEqual(broken.Cursors, expected) {
+		t.Errorf("expected: %v, actual: %v", expected, broken.Cursors)
+	}
+}
+
+func TestRenderCursorInFill(t *testing.T) {
+	ds := []Doc{
+		Text("foo"),
+		Concat([]Doc{Text("b"), Cursor(), Text("ar")}),
+		Text("baz"),
+	}
+	doc := Fill(Concat([]Doc{Text(","), Line()}), ds)
+	for _, width := range []int{80, 9, 0} {
+		result := Render(width, doc)
+		if len(result.Cursors) != 1 {
+			t.Fatalf("expected a cursor, actual: %v", result.Cursors)
+		}
+		cursor := result.Cursors[0]
+		if result.Output[cursor.Offset-1:cursor.Offset+2] != "bar" {
+			t.Errorf("cursor should be placed between \"b\" and \"ar\": %v, %v", result.Output, cursor)
+		}
+	}
+}
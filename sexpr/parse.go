@@ -0,0 +1,166 @@
+package sexpr
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Kind is the kind of an Expr.
+type Kind int
+
+// Kinds of Expr.
+const (
+	KindAtom Kind = iota
+	KindString
+	KindList
+	KindComment
+)
+
+// Expr is an S-expression, or a comment between S-expressions.
+type Expr struct {
+	Kind Kind
+	// Text is the text of an atom, a string (with the quotes and escapes
+	// as they are written) or a comment (with the semicolons).
+	Text string
+	// Prefix is the quote ("'", "`", "," or ",@") before the expression.
+	Prefix string
+	// List is the elements of a list, including comments.
+	List []*Expr
+	// Inline reports whether a comment follows the previous expression
+	// on the same line.
+	Inline bool
+	// BlankBefore reports whether there is a blank line before the
+	// expression.
+	BlankBefore bool
+}
+
+// Parse parses the S-expressions and the comments between them in src.
+func Parse(src string) ([]*Expr, error) {
+	s := &scanner{src: src, line: 1, col: 1}
+	exprs, err := s.exprs(false)
+	if err != nil {
+		return nil, err
+	}
+	return exprs, nil
+}
+
+type scanner struct {
+	src  string
+	pos  int
+	line int
+	col  int
+}
+
+func (s *scanner) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("sexpr: %d:%d: %s", s.line, s.col, fmt.Sprintf(format, args...))
+}
+
+func (s *scanner) next() byte {
+	c := s.src[s.pos]
+	s.pos++
+	if c == '\n' {
+		s.line++
+		s.col = 1
+	} else {
+		s.col++
+	}
+	return c
+}
+
+// skipSpaces skips spaces, and reports the number of newlines skipped.
+func (s *scanner) skipSpaces() int {
+	newlines := 0
+	for s.pos < len(s.src) && strings.IndexByte(" \t\r\n", s.src[s.pos]) >= 0 {
+		if s.next() == '\n' {
+			newlines++
+		}
+	}
+	return newlines
+}
+
+// exprs parses expressions until the end of the input, or the closing
+// parenthesis if inList is set.
+func (s *scanner) exprs(inList bool) ([]*Expr, error) {
+	exprs := []*Expr{}
+	for {
+		newlines := s.skipSpaces()
+		if s.pos >= len(s.src) {
+			if inList {
+				return nil, s.errorf("unclosed list")
+			}
+			return exprs, nil
+		}
+		if s.src[s.pos] == ')' {
+			if !inList {
+				return nil, s.errorf("unexpected ')'")
+			}
+			s.next()
+			return exprs, nil
+		}
+		var e *Expr
+		if s.src[s.pos] == ';' {
+			start := s.pos
+			for s.pos < len(s.src) && s.src[s.pos] != '\n' {
+				s.next()
+			}
+			e = &Expr{
+				Kind:   KindComment,
+				Text:   strings.TrimRight(s.src[start:s.pos], " \t\r"),
+				Inline: len(exprs) > 0 && newlines == 0,
+			}
+		} else {
+			var err error
+			if e, err = s.expr(); err != nil {
+				return nil, err
+			}
+		}
+		e.BlankBefore = len(exprs) > 0 && newlines > 1
+		exprs = append(exprs, e)
+	}
+}
+
+func (s *scanner) expr() (*Expr, error) {
+	prefix := ""
+	for s.pos < len(s.src) && strings.IndexByte("'`,", s.src[s.pos]) >= 0 {
+		prefix += string(s.next())
+		if prefix[len(prefix)-1] == ',' && s.pos < len(s.src) && s.src[s.pos] == '@' {
+			prefix += string(s.next())
+		}
+	}
+	if s.pos >= len(s.src) {
+		return nil, s.errorf("unexpected end of input after %q", prefix)
+	}
+	switch s.src[s.pos] {
+	case '(':
+		s.next()
+		list, err := s.exprs(true)
+		if err != nil {
+			return nil, err
+		}
+		return &Expr{Kind: KindList, Prefix: prefix, List: list}, nil
+	case '"':
+		start := s.pos
+		s.next()
+		for {
+			if s.pos >= len(s.src) {
+				return nil, s.errorf("unterminated string")
+			}
+			c := s.next()
+			if c == '\\' && s.pos < len(s.src) {
+				s.next()
+			} else if c == '"' {
+				break
+			}
+		}
+		return &Expr{Kind: KindString, Prefix: prefix, Text: s.src[start:s.pos]}, nil
+	default:
+		start := s.pos
+		for s.pos < len(s.src) && strings.IndexByte(" \t\r\n()\";", s.src[s.pos]) < 0 {
+			s.next()
+		}
+		if start == s.pos {
+			return nil, s.errorf("unexpected %q", s.src[s.pos])
+		}
+		return &Expr{Kind: KindAtom, Prefix: prefix, Text: s.src[start:s.pos]}, nil
+	}
+}
@@ -0,0 +1,45 @@
+package sexpr
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	exprs, err := Parse("(a \"b\\\" c\" 'd ,@(e)) ; x\n\n; y\nf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []*Expr{
+		{Kind: KindList, List: []*Expr{
+			{Kind: KindAtom, Text: "a"},
+			{Kind: KindString, Text: `"b\" c"`},
+			{Kind: KindAtom, Text: "d", Prefix: "'"},
+			{Kind: KindList, Prefix: ",@", List: []*Expr{{Kind: KindAtom, Text: "e"}}},
+		}},
+		{Kind: KindComment, Text: "; x", Inline: true},
+		{Kind: KindComment, Text: "; y", BlankBefore: true},
+		{Kind: KindAtom, Text: "f"},
+	}
+	if !reflect.DeepEqual(exprs, expected) {
+		t.Errorf("expected: %v, actual: %v", expected, exprs)
+	}
+}
+
+func TestParseError(t *testing.T) {
+	tests := []struct {
+		src      string
+		expected string
+	}{
+		{"(a\n (b)", "sexpr: 2:5: unclosed list"},
+		{"a)", "sexpr: 1:2: unexpected ')'"},
+		{`("a)`, "sexpr: 1:5: unterminated string"},
+		{"'", `sexpr: 1:2: unexpected end of input after "'"`},
+	}
+	for _, tt := range tests {
+		_, err := Parse(tt.src)
+		if err == nil || err.Error() != tt.expected {
+			t.Errorf("expected: %v, actual: %v", tt.expected, err)
+		}
+	}
+}
@@ -0,0 +1,223 @@
+/*
+Package sexpr parses S-expressions and lays them out as documents of
+prettier in the conventional style of Lisp.
+
+A list is laid out on one line if it fits. Otherwise the head of the list
+stays on the first line, and the rest of the elements are aligned with
+the first argument:
+
+	(foo bar
+	     baz)
+
+Special forms such as `define` keep their distinguished arguments on the
+first line, and their bodies are indented:
+
+	(define (square x)
+	  (* x x))
+
+Elements which are all atoms or strings, such as the arguments of
+`(list 1 2 3)`, are filled as many as possible on each line.
+Comments are kept, and the lists enclosing comments are broken into lines.
+*/
+package sexpr
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	p "github.com/tanishiking/prettier"
+)
+
+// DefaultSpecialForms is the special forms used when Options.SpecialForms
+// is nil, mapped to the number of their distinguished arguments.
+var DefaultSpecialForms = map[string]int{
+	"begin":    0,
+	"case":     1,
+	"define":   1,
+	"defmacro": 2,
+	"defun":    2,
+	"do":       2,
+	"lambda":   1,
+	"let":      1,
+	"let*":     1,
+	"letrec":   1,
+	"progn":    0,
+	"unless":   1,
+	"when":     1,
+}
+
+// Options configures how S-expressions are laid out.
+type Options struct {
+	// Indent is the indentation of the bodies of special forms.
+	// Zero means 2.
+	Indent uint
+	// SpecialForms maps the heads of special forms to the number of their
+	// distinguished arguments, which are laid out on the first line.
+	// The rest of the arguments are the body, which is indented by Indent.
+	// Nil means DefaultSpecialForms.
+	SpecialForms map[string]int
+}
+
+// Format parses the S-expressions in src, and renders them within the
+// width when possible.
+func Format(src string, width int, opts Options) (string, error) {
+	exprs, err := Parse(src)
+	if err != nil {
+		return "", err
+	}
+	return p.Pretty(width, Doc(exprs, opts)), nil
+}
+
+// Doc lays out the top-level expressions, one on each line.
+// Blank lines between the expressions are kept.
+func Doc(exprs []*Expr, opts Options) p.Doc {
+	if opts.Indent == 0 {
+		opts.Indent = 2
+	}
+	if opts.SpecialForms == nil {
+		opts.SpecialForms = DefaultSpecialForms
+	}
+	l := &layout{opts: opts}
+	ds := []p.Doc{}
+	for i, e := range exprs {
+		if i > 0 {
+			ds = append(ds, l.separator(e))
+			if e.BlankBefore && !e.Inline {
+				ds = append(ds, p.LineBreak())
+			}
+		}
+		doc, _ := l.expr(e)
+		ds = append(ds, doc)
+	}
+	return p.Concat(ds)
+}
+
+type layout struct {
+	opts Options
+}
+
+// expr lays out the expression, and reports whether it has a comment or
+// a multi-line string, which requires the enclosing lists to be broken.
+func (l *layout) expr(e *Expr) (p.Doc, bool) {
+	switch e.Kind {
+	case KindList:
+		doc, hard := l.list(e.List)
+		return p.Concat([]p.Doc{p.Text(e.Prefix), doc}), hard
+	case KindComment:
+		return p.Text(e.Text), true
+	default:
+		return text(e.Prefix + e.Text), strings.Contains(e.Text, "\n")
+	}
+}
+
+// text is the text of an atom or a string. The lines of a multi-line
+// string are kept as they are, and its length is the width of its last
+// line, so that the column after it is measured from its last line.
+func text(s string) p.Doc {
+	if i := strings.LastIndexByte(s, '\n'); i >= 0 {
+		return p.TextWithLength(s, utf8.RuneCountInString(s[i+1:]))
+	}
+	return p.Text(s)
+}
+
+func (l *layout) list(elems []*Expr) (p.Doc, bool) {
+	if len(elems) == 0 {
+		return p.Text("()"), false
+	}
+	docs := make([]p.Doc, len(elems))
+	hard := false
+	for i, e := range elems {
+		var h bool
+		docs[i], h = l.expr(e)
+		hard = hard || h
+	}
+	// a comment at the end must be followed by a newline.
+	last := p.Empty()
+	if elems[len(elems)-1].Kind == KindComment {
+		last = p.LineBreak()
+	}
+	head := elems[0]
+	var doc p.Doc
+	ownLine := len(elems) > 1 && elems[1].Kind == KindComment && !elems[1].Inline
+	if head.Kind != KindAtom || head.Prefix != "" || isLiteral(head.Text) || ownLine {
+		// a list of data, or a list whose arguments start on the next line.
+		doc = p.Concat([]p.Doc{p.Text("("), p.Align(p.Concat([]p.Doc{l.join(elems, docs), last})), p.Text(")")})
+	} else if n, ok := l.opts.SpecialForms[head.Text]; ok {
+		doc = l.specialForm(elems, docs, n, last)
+	} else if len(elems) == 1 {
+		doc = p.Concat([]p.Doc{p.Text("("), docs[0], last, p.Text(")")})
+	} else {
+		// a call, whose arguments are aligned with the first one.
+		doc = p.Concat([]p.Doc{
+			p.Text("("),
+			docs[0],
+			p.Text(" "),
+			p.Align(p.Concat([]p.Doc{l.join(elems[1:], docs[1:]), last})),
+			p.Text(")"),
+		})
+	}
+	// the indentation of the body of the list is relative to the opening
+	// parenthesis.
+	doc = p.Align(doc)
+	if hard {
+		// lines out of groups are always broken.
+		return doc, true
+	}
+	return p.Group(doc), false
+}
+
+func (l *layout) specialForm(elems []*Expr, docs []p.Doc, n int, last p.Doc) p.Doc {
+	n++
+	if n > len(elems) {
+		n = len(elems)
+	}
+	ds := []p.Doc{p.Text("("), docs[0]}
+	if n > 1 {
+		ds = append(ds, p.Text(" "), p.Align(l.join(elems[1:n], docs[1:n])))
+	}
+	if n < len(elems) {
+		body := l.join(elems[n:], docs[n:])
+		ds = append(ds, p.Nest(l.opts.Indent, p.Concat([]p.Doc{l.separator(elems[n]), body})))
+	}
+	ds = append(ds, last, p.Text(")"))
+	return p.Concat(ds)
+}
+
+// join joins the laid out elements with lines. Atoms and strings are
+// filled in lines, and comments are kept on the same line if they are
+// written so.
+func (l *layout) join(elems []*Expr, docs []p.Doc) p.Doc {
+	filled := true
+	for _, e := range elems {
+		filled = filled && (e.Kind == KindAtom || e.Kind == KindString)
+	}
+	if filled {
+		return p.Fill(p.Line(), docs)
+	}
+	ds := []p.Doc{}
+	for i, doc := range docs {
+		if i > 0 {
+			ds = append(ds, l.separator(elems[i]))
+		}
+		ds = append(ds, doc)
+	}
+	return p.Concat(ds)
+}
+
+// separator is the separator before the element.
+func (l *layout) separator(e *Expr) p.Doc {
+	if e.Kind == KindComment && e.Inline {
+		return p.Text(" ")
+	}
+	return p.Line()
+}
+
+// isLiteral reports whether the atom is a literal such as a number,
+// rather than a symbol.
+func isLiteral(atom string) bool {
+	c := atom[0]
+	if c == '-' || c == '+' || c == '.' {
+		return len(atom) > 1 && atom[1] >= '0' && atom[1] <= '9'
+	}
+	return c >= '0' && c <= '9' || c == '#' || c == ':'
+}
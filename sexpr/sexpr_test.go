@@ -0,0 +1,107 @@
+package sexpr
+
+import (
+	"fmt"
+	"testing"
+)
+
+const src = `(define (fact n) (if (= n 0) 1 (* n (fact (- n 1)))))
+
+; numbers
+(list 1 2 3 4 5 6 7 8 9 10 11 12 13 14 15 16 17 18 19 20)
+(let ((a 1) (b 2)) ; inline
+  (+ a b))`
+
+func format(t *testing.T, src string, width int, opts Options) string {
+	t.Helper()
+	actual, err := Format(src, width, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return actual
+}
+
+func TestFormat(t *testing.T) {
+	expected := `(define (fact n) (if (= n 0) 1 (* n (fact (- n 1)))))
+
+; numbers
+(list 1 2 3 4 5 6 7 8 9 10 11 12 13 14 15 16 17 18 19 20)
+(let ((a 1) (b 2)) ; inline
+  (+ a b))`
+	if actual := format(t, src, 80, Options{}); actual != expected {
+		t.Errorf("expected: %v, actual: %v", expected, actual)
+	}
+	expected = `(define (fact n)
+  (if (= n 0)
+      1
+      (* n (fact (- n 1)))))
+
+; numbers
+(list 1 2 3 4 5 6 7 8 9 10 11
+      12 13 14 15 16 17 18 19
+      20)
+(let ((a 1) (b 2)) ; inline
+  (+ a b))`
+	if actual := format(t, src, 30, Options{}); actual != expected {
+		t.Errorf("expected: %v, actual: %v", expected, actual)
+	}
+}
+
+func TestFormatIdempotent(t *testing.T) {
+	for _, width := range []int{10, 30, 80} {
+		once := format(t, src, width, Options{})
+		if twice := format(t, once, width, Options{}); twice != once {
+			t.Errorf("expected: %v, actual: %v", once, twice)
+		}
+	}
+}
+
+func TestFormatOptions(t *testing.T) {
+	opts := Options{
+		Indent:       4,
+		SpecialForms: map[string]int{"if": 1},
+	}
+	expected := `(if (= n 0)
+    1
+    (define (f x)
+            x))`
+	if actual := format(t, "(if (= n 0) 1 (define (f x) x))", 20, opts); actual != expected {
+		t.Errorf("expected: %v, actual: %v", expected, actual)
+	}
+}
+
+func TestFormatComments(t *testing.T) {
+	expected := `((lambda (x) x)
+ 1 ; one
+ )`
+	if actual := format(t, "((lambda (x) x) 1 ; one\n)", 80, Options{}); actual != expected {
+		t.Errorf("expected: %v, actual: %v", expected, actual)
+	}
+	expected = `(foo
+ ; own line
+ bar)`
+	if actual := format(t, "(foo\n; own line\nbar)", 80, Options{}); actual != expected {
+		t.Errorf("expected: %v, actual: %v", expected, actual)
+	}
+}
+
+func TestFormatMultilineString(t *testing.T) {
+	// the atoms after the string are filled from its last line.
+	expected := "(list \"abcdefghijkl\nmn\" 1 2 3\n      4 5 6 7 8\n      9)"
+	if actual := format(t, "(list \"abcdefghijkl\nmn\" 1 2 3 4 5 6 7 8 9)", 16, Options{}); actual != expected {
+		t.Errorf("expected: %q, actual: %q", expected, actual)
+	}
+}
+
+func ExampleFormat() {
+	out, err := Format(`(define (square x) (* x x)) (map square '(1 2 3))`, 20, Options{})
+	if err != nil {
+		panic(err)
+	}
+	fmt.Println(out)
+	// Output:
+	// (define (square x)
+	//   (* x x))
+	// (map square
+	//      '(1 2 3))
+}
@@ -0,0 +1,128 @@
+package prettier
+
+// Simplify returns a document which is rendered exactly the same as
+// the given Doc for every width, but is made of fewer nodes:
+//
+//   - empty documents and empty texts in concatenations are removed.
+//   - adjacent texts are fused into one text.
+//   - nested Nest are merged into one Nest, and Nest(0, x) becomes x.
+//     Nest and Align around a document without lines are removed.
+//   - nested groups are merged into one group.
+//   - unions and IfBreak whose branches are the same are replaced with
+//     the branch.
+//
+// Lazy documents stay lazy; they are simplified when they are evaluated.
+func Simplify(doc Doc) Doc {
+	switch v := doc.(type) {
+	case *concat:
+		parts := appendSimplified([]Doc{}, v)
+		if len(parts) == 0 {
+			return Empty()
+		}
+		return Concat(parts)
+	case *nest:
+		inner := Simplify(v.doc)
+		if v.indent == 0 {
+			return inner
+		}
+		switch n := inner.(type) {
+		case *nest:
+			return &nest{
+				indent: v.indent + n.indent,
+				doc:    n.doc,
+			}
+		case *empty, *text, *cursor:
+			return inner
+		}
+		return &nest{
+			indent: v.indent,
+			doc:    inner,
+		}
+	case *align:
+		inner := Simplify(v.doc)
+		switch inner.(type) {
+		case *empty, *text, *cursor, *align:
+			return inner
+		}
+		return &align{
+			doc: inner,
+		}
+	case *group:
+		return Group(Simplify(v.doc))
+	case *ifBreak:
+		broken := Simplify(v.broken)
+		flat := Simplify(v.flat)
+		if Equal(broken, flat) {
+			return broken
+		}
+		return &ifBreak{broken: broken, flat: flat}
+	case *fill:
+		parts := make([]Doc, len(v.parts))
+		for i, part := range v.parts {
+			parts[i] = Simplify(part)
+		}
+		return &fill{
+			sep:   Simplify(v.sep),
+			parts: parts,
+		}
+	case *union:
+		a := Simplify(v.a)
+		b := Simplify(v.b)
+		if Equal(a, b) {
+			return a
+		}
+		return &union{a: a, b: b}
+	case *lazyDoc:
+		return lazy(func() Doc {
+			return Simplify(v.Evaluated())
+		})
+	case *annotation:
+		return &annotation{
+			value: v.value,
+			doc:   Simplify(v.doc),
+		}
+	default:
+		return doc
+	}
+}
+
+// appendSimplified appends simplified parts of the given concatenation to
+// parts, which doesn't have empty documents nor adjacent texts.
+func appendSimplified(parts []Doc, doc Doc) []Doc {
+	if c, ok := doc.(*concat); ok {
+		parts = appendSimplified(parts, c.a)
+		return appendSimplified(parts, c.b)
+	}
+	simplified := Simplify(doc)
+	// Simplify(concat) is a concatenation of simplified parts.
+	for {
+		c, ok := simplified.(*concat)
+		if !ok {
+			break
+		}
+		parts = appendPart(parts, c.a)
+		simplified = c.b
+	}
+	return appendPart(parts, simplified)
+}
+
+func appendPart(parts []Doc, part Doc) []Doc {
+	switch v := part.(type) {
+	case *empty:
+		return parts
+	case *text:
+		if v.str == "" && v.length == 0 {
+			return parts
+		}
+		if len(parts) > 0 {
+			if last, ok := parts[len(parts)-1].(*text); ok {
+				parts[len(parts)-1] = &text{
+					str:    last.str + v.str,
+					length: last.length + v.length,
+				}
+				return parts
+			}
+		}
+	}
+	return append(parts, part)
+}
@@ -0,0 +1,110 @@
+package prettier
+
+import (
+	"math/rand"
+	"reflect"
+	"testing"
+	"testing/quick"
+)
+
+func TestSimplify(t *testing.T) {
+	tests := []struct {
+		doc      Doc
+		expected Doc
+	}{
+		{
+			Concat([]Doc{Empty(), Text("a"), Empty()}),
+			Text("a"),
+		},
+		{
+			Concat([]Doc{Empty(), Empty()}),
+			Empty(),
+		},
+		{
+			Concat([]Doc{Text("a"), Concat([]Doc{Text(""), Text("b")}), Line(), Text("c"), TextWithLength("d", 0)}),
+			Concat([]Doc{Text("ab"), Line(), TextWithLength("cd", 1)}),
+		},
+		{
+			Nest(uint(2), Nest(uint(0), Nest(uint(3), Line()))),
+			Nest(uint(5), Line()),
+		},
+		{
+			Nest(uint(2), Concat([]Doc{Text("a"), Text("b")})),
+			Text("ab"),
+		},
+		{
+			Concat([]Doc{Text("a"), Nest(uint(0), Concat([]Doc{Text("b"), Line()}))}),
+			Concat([]Doc{Text("ab"), Line()}),
+		},
+		{
+			&union{a: Concat([]Doc{Text("a"), Empty()}), b: Text("a")},
+			Text("a"),
+		},
+		{
+			Annotate("id", Concat([]Doc{Text("a"), Text("b")})),
+			Annotate("id", Text("ab")),
+		},
+	}
+	for _, tt := range tests {
+		actual := Simplify(tt.doc)
+		if !reflect.DeepEqual(actual, tt.expected) {
+			t.Errorf("expected: %v, actual: %v", tt.expected, actual)
+		}
+	}
+}
+
+func TestSimplifyLazy(t *testing.T) {
+	evaluated := false
+	doc := Simplify(lazy(func() Doc {
+		evaluated = true
+		return Concat([]Doc{Text("a"), Text("b")})
+	}))
+	if evaluated {
+		t.Errorf("Simplify should not evaluate lazy documents")
+	}
+	if Pretty(80, doc) != "ab" {
+		t.Errorf("expected: %v, actual: %v", "ab", Pretty(80, doc))
+	}
+}
+
+func TestSimplifyPreservesLayout(t *testing.T) {
+	cfg := &quick.Config{
+		Values: func(args []reflect.Value, r *rand.Rand) {
+			args[0] = reflect.ValueOf(generateRandomTree(r, 4))
+		},
+	}
+	f := func(doc Doc) bool {
+		simplified := Simplify(doc)
+		for width := 0; width < 30; width++ {
+			if Pretty(width, doc) != Pretty(width, simplified) {
+				return false
+			}
+		}
+		return true
+	}
+	if err := quick.Check(f, cfg); err != nil {
+		t.Errorf("Simplify changed the layout: %v", err)
+	}
+}
+
+// generateRandomTree generates a random Doc with nested structures.
+func generateRandomTree(r *rand.Rand, depth int) Doc {
+	if depth == 0 {
+		return generateRandomDoc()
+	}
+	switch r.Intn(6) {
+	case 0:
+		return Concat([]Doc{generateRandomTree(r, depth-1), generateRandomTree(r, depth-1)})
+	case 1:
+		return Nest(uint(r.Intn(3)), generateRandomTree(r, depth-1))
+	case 2:
+		return Group(generateRandomTree(r, depth-1))
+	case 3:
+		parts := []Doc{generateRandomTree(r, depth-1), generateRandomTree(r, depth-1), generateRandomTree(r, depth-1)}
+		return Fill(Concat([]Doc{Text(","), Line()}), parts)
+	case 4:
+		return Annotate(depth, generateRandomTree(r, depth-1))
+	default:
+		return generateRandomDoc()
+	}
+}
@@ -0,0 +1,206 @@
+package prettier
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+	"unicode/utf16"
+)
+
+// SourcePos is an annotation value which points to a location
+// in an original source file. Line and Column are zero-based, and Column
+// is counted in UTF-16 code units as in source maps.
+//
+// Spans annotated with SourcePos are turned into mappings by SourceMap.
+type SourcePos struct {
+	Source string `json:"source"`
+	Line   int    `json:"line"`
+	Column int    `json:"column"`
+	// Name is an optional original name of the symbol.
+	Name string `json:"name,omitempty"`
+}
+
+type sourceMapV3 struct {
+	Version  int      `json:"version"`
+	File     string   `json:"file,omitempty"`
+	Sources  []string `json:"sources"`
+	Names    []string `json:"names"`
+	Mappings string   `json:"mappings"`
+}
+
+type sourceMapEvent struct {
+	pos   Position
+	start bool
+	span  int
+}
+
+// SourceMap encodes the spans of the result whose annotations are
+// SourcePos as a source map (revision 3) in JSON.
+// `file` is the name of the generated file, and may be empty.
+//
+// Each annotated range of the output is mapped to the start of
+// its SourcePos, from its start and from the start of each of the
+// following lines in the range. Outside of annotated ranges, the output is
+// unmapped. The columns of the output are converted from bytes into UTF-16
+// code units, which are the columns of source maps.
+func SourceMap(file string, result *Result) ([]byte, error) {
+	events := []sourceMapEvent{}
+	for i, s := range result.Spans {
+		if _, ok := s.Annotation.(SourcePos); !ok || s.Start.Offset == s.End.Offset {
+			continue
+		}
+		events = append(events,
+			sourceMapEvent{pos: utf16Position(result.Output, s.Start), start: true, span: i},
+			sourceMapEvent{pos: utf16Position(result.Output, s.End), start: false, span: i},
+		)
+	}
+	sort.SliceStable(events, func(i, j int) bool {
+		if events[i].pos.Offset != events[j].pos.Offset {
+			return events[i].pos.Offset < events[j].pos.Offset
+		}
+		// close spans before opening the adjacent ones.
+		return !events[i].start && events[j].start
+	})
+
+	m := &sourceMapV3{
+		Version: 3,
+		File:    file,
+		Sources: []string{},
+		Names:   []string{},
+	}
+	sources := map[string]int{}
+	names := map[string]int{}
+	enc := &mappingsEncoder{}
+	stack := []int{}
+	for i := 0; i < len(events); {
+		pos := events[i].pos
+		for ; i < len(events) && events[i].pos.Offset == pos.Offset; i++ {
+			if events[i].start {
+				stack = append(stack, events[i].span)
+			} else {
+				stack = stack[:len(stack)-1]
+			}
+		}
+		if len(stack) == 0 {
+			enc.unmapped(pos)
+			continue
+		}
+		src := result.Spans[stack[len(stack)-1]].Annotation.(SourcePos)
+		srcIndex, ok := sources[src.Source]
+		if !ok {
+			srcIndex = len(m.Sources)
+			sources[src.Source] = srcIndex
+			m.Sources = append(m.Sources, src.Source)
+		}
+		nameIndex := -1
+		if src.Name != "" {
+			nameIndex, ok = names[src.Name]
+			if !ok {
+				nameIndex = len(m.Names)
+				names[src.Name] = nameIndex
+				m.Names = append(m.Names, src.Name)
+			}
+		}
+		enc.mapped(pos, srcIndex, src.Line, src.Column, nameIndex)
+		// a mapping doesn't continue to the next line, so the span is
+		// mapped again at the start of each of its lines.
+		for line := pos.Line + 1; i < len(events) && line <= events[i].pos.Line; line++ {
+			if line == events[i].pos.Line && events[i].pos.Column == 0 {
+				break
+			}
+			enc.mapped(Position{Line: line}, srcIndex, src.Line, src.Column, nameIndex)
+		}
+	}
+	m.Mappings = enc.String()
+	return json.Marshal(m)
+}
+
+// utf16Position converts the column of the position in the output from
+// bytes into UTF-16 code units.
+func utf16Position(output string, pos Position) Position {
+	column := 0
+	for _, r := range output[pos.Offset-pos.Column : pos.Offset] {
+		column += len(utf16.Encode([]rune{r}))
+	}
+	pos.Column = column
+	return pos
+}
+
+// mappingsEncoder builds the "mappings" field of a source map.
+// See https://sourcemaps.info/spec.html
+type mappingsEncoder struct {
+	sb        strings.Builder
+	line      int
+	col       int
+	source    int
+	srcLine   int
+	srcCol    int
+	name      int
+	emitted   bool
+	hasMapped bool
+}
+
+func (e *mappingsEncoder) advance(pos Position) {
+	for e.line < pos.Line {
+		e.sb.WriteByte(';')
+		e.line++
+		e.col = 0
+		e.emitted = false
+		e.hasMapped = false
+	}
+	if e.emitted {
+		e.sb.WriteByte(',')
+	}
+	e.emitted = true
+	writeVLQ(&e.sb, pos.Column-e.col)
+	e.col = pos.Column
+}
+
+func (e *mappingsEncoder) unmapped(pos Position) {
+	// A mapping doesn't continue to the next line, so an unmapped
+	// segment is needed only to stop a mapping in the same line.
+	if pos.Line != e.line || !e.hasMapped {
+		return
+	}
+	e.advance(pos)
+	e.hasMapped = false
+}
+
+func (e *mappingsEncoder) mapped(pos Position, source int, line int, col int, name int) {
+	e.advance(pos)
+	e.hasMapped = true
+	writeVLQ(&e.sb, source-e.source)
+	writeVLQ(&e.sb, line-e.srcLine)
+	writeVLQ(&e.sb, col-e.srcCol)
+	e.source = source
+	e.srcLine = line
+	e.srcCol = col
+	if name >= 0 {
+		writeVLQ(&e.sb, name-e.name)
+		e.name = name
+	}
+}
+
+func (e *mappingsEncoder) String() string {
+	return e.sb.String()
+}
+
+const base64Digits = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789+/"
+
+func writeVLQ(sb *strings.Builder, n int) {
+	v := n << 1
+	if n < 0 {
+		v = (-n << 1) | 1
+	}
+	for {
+		digit := v & 0x1f
+		v >>= 5
+		if v > 0 {
+			digit |= 0x20
+		}
+		sb.WriteByte(base64Digits[digit])
+		if v == 0 {
+			return
+		}
+	}
+}
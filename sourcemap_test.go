@@ -0,0 +1,95 @@
+package prettier
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSourceMap(t *testing.T) {
+	doc := Concat([]Doc{
+		Annotate(SourcePos{Source: "a.src", Line: 0, Column: 0, Name: "f"}, Text("f")),
+		Text("("),
+		Annotate(SourcePos{Source: "a.src", Line: 1, Column: 4}, Text("x")),
+		Text(")"),
+		Line(),
+		Annotate(SourcePos{Source: "b.src", Line: 0, Column: 2}, Text("y")),
+	})
+	result := Render(80, doc)
+	if result.Output != "f(x)\ny" {
+		t.Fatalf("expected: %q, actual: %q", "f(x)\ny", result.Output)
+	}
+	m, err := SourceMap("out.txt", result)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `{"version":3,"file":"out.txt","sources":["a.src","b.src"],"names":["f"],"mappings":"AAAAA,C,CACI,C;ACDF,C"}`
+	if string(m) != expected {
+		t.Errorf("expected: %v, actual: %v", expected, string(m))
+	}
+}
+
+func TestSourceMapLines(t *testing.T) {
+	doc := Concat([]Doc{
+		Annotate(SourcePos{Source: "a.src", Line: 2, Column: 1}, Concat([]Doc{
+			Text("a"),
+			LineBreak(),
+			Text("bc"),
+			LineBreak(),
+			Annotate(SourcePos{Source: "a.src", Line: 3, Column: 0}, Text("d")),
+		})),
+		Text(" e"),
+	})
+	result := Render(80, doc)
+	if result.Output != "a\nbc\nd e" {
+		t.Fatalf("expected: %q, actual: %q", "a\nbc\nd e", result.Output)
+	}
+	m, err := SourceMap("", result)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the second line is mapped from its start, and the third line starts
+	// with the inner span.
+	expected := `{"version":3,"sources":["a.src"],"names":[],"mappings":"AAEC;AAAA;AACD,C"}`
+	if string(m) != expected {
+		t.Errorf("expected: %v, actual: %v", expected, string(m))
+	}
+}
+
+func TestSourceMapUTF16(t *testing.T) {
+	doc := Concat([]Doc{
+		Text("é😀"),
+		Annotate(SourcePos{Source: "a.src", Line: 0, Column: 3}, Text("x")),
+	})
+	result := Render(80, doc)
+	if result.Spans[0].Start.Column != 6 {
+		t.Fatalf("expected: %v, actual: %v", 6, result.Spans[0].Start.Column)
+	}
+	m, err := SourceMap("", result)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// "é" is a code unit and "😀" is two code units in UTF-16.
+	expected := `{"version":3,"sources":["a.src"],"names":[],"mappings":"GAAG,C"}`
+	if string(m) != expected {
+		t.Errorf("expected: %v, actual: %v", expected, string(m))
+	}
+}
+
+func TestWriteVLQ(t *testing.T) {
+	tests := map[int]string{
+		0:    "A",
+		1:    "C",
+		-1:   "D",
+		15:   "e",
+		16:   "gB",
+		-16:  "hB",
+		1000: "w+B",
+	}
+	for n, expected := range tests {
+		var sb strings.Builder
+		writeVLQ(&sb, n)
+		if sb.String() != expected {
+			t.Errorf("%v: expected: %v, actual: %v", n, expected, sb.String())
+		}
+	}
+}
@@ -0,0 +1,476 @@
+/*
+Package sql formats SQL statements with prettier.
+
+The statements are tokenized and parsed lightly: each statement is split
+into clauses such as SELECT, FROM and WHERE at the top level of the
+statement, and the parenthesized subqueries are formatted recursively.
+A statement is written on one line if it fits, and otherwise every clause
+starts on a new line:
+
+	SELECT id, name, email, created_at
+	FROM users
+	WHERE active = TRUE
+	  AND created_at > $1
+	ORDER BY created_at DESC
+
+The lists of columns are filled in lines, and the conditions are broken
+before AND and OR when they don't fit. Other syntax is kept as it is,
+with the spaces between the tokens normalized.
+*/
+package sql
+
+import (
+	"fmt"
+	"strings"
+
+	p "github.com/tanishiking/prettier"
+)
+
+// Case is how keywords are cased.
+type Case int
+
+// Cases of keywords.
+const (
+	// CasePreserve keeps keywords as they are written.
+	CasePreserve Case = iota
+	// CaseUpper writes keywords in upper case.
+	CaseUpper
+	// CaseLower writes keywords in lower case.
+	CaseLower
+)
+
+// Options configures how SQL is formatted.
+type Options struct {
+	// Indent is the indentation of the continuation lines of clauses and
+	// of subqueries. Zero means 2.
+	Indent uint
+	// Keywords is how keywords are cased.
+	Keywords Case
+}
+
+// Format formats the SQL statements in src within the width when possible.
+func Format(src string, width int, opts Options) (string, error) {
+	doc, err := Doc(src, opts)
+	if err != nil {
+		return "", err
+	}
+	return p.Pretty(width, doc), nil
+}
+
+// Doc lays out the SQL statements in src, one on each line.
+func Doc(src string, opts Options) (p.Doc, error) {
+	toks, err := Tokenize(src)
+	if err != nil {
+		return nil, err
+	}
+	elems, err := parse(toks)
+	if err != nil {
+		return nil, err
+	}
+	if opts.Indent == 0 {
+		opts.Indent = 2
+	}
+	l := &layout{opts: opts}
+	ds := []p.Doc{}
+	for _, stmt := range split(elems, ";") {
+		if len(ds) > 0 {
+			ds = append(ds, p.LineBreak())
+		}
+		n := len(stmt)
+		for n > 0 && stmt[n-1].tok.Kind == KindComment {
+			n--
+		}
+		if n == 0 || stmt[n-1].tok.Text != ";" {
+			doc, _ := l.statement(stmt)
+			ds = append(ds, doc)
+			continue
+		}
+		// the semicolon, followed by the comments on the same line.
+		doc, _ := l.statement(stmt[:n-1])
+		if n > 1 && isLineComment(stmt[n-2].tok) {
+			doc = p.Concat([]p.Doc{doc, p.LineBreak()})
+		}
+		ds = append(ds, doc, p.Text(";"))
+		for _, e := range stmt[n:] {
+			ds = append(ds, p.Text(" "), l.token(e.tok))
+		}
+	}
+	return p.Concat(ds), nil
+}
+
+// elem is a token, or the tokens in parentheses.
+type elem struct {
+	tok Token
+	// inner is the elements between the parentheses if tok is "(".
+	inner []elem
+}
+
+// parse groups the tokens in parentheses.
+func parse(toks []Token) ([]elem, error) {
+	stack := [][]elem{{}}
+	opens := []Token{}
+	for _, tok := range toks {
+		switch tok.Text {
+		case "(":
+			stack = append(stack, []elem{})
+			opens = append(opens, tok)
+		case ")":
+			if len(opens) == 0 {
+				return nil, fmt.Errorf("sql: %d:%d: unexpected ')'", tok.Line, tok.Col)
+			}
+			inner := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			open := opens[len(opens)-1]
+			opens = opens[:len(opens)-1]
+			stack[len(stack)-1] = append(stack[len(stack)-1], elem{tok: open, inner: inner})
+		default:
+			stack[len(stack)-1] = append(stack[len(stack)-1], elem{tok: tok})
+		}
+	}
+	if len(opens) > 0 {
+		open := opens[len(opens)-1]
+		return nil, fmt.Errorf("sql: %d:%d: unclosed '('", open.Line, open.Col)
+	}
+	return stack[0], nil
+}
+
+// split splits the elements after each separator, which is kept at the
+// end of the parts together with the comments on the same line.
+func split(elems []elem, sep string) [][]elem {
+	parts := [][]elem{}
+	start := 0
+	for i := 0; i < len(elems); i++ {
+		e := elems[i]
+		if e.tok.Kind == KindOperator && e.tok.Text == sep && e.inner == nil {
+			for i+1 < len(elems) && elems[i+1].tok.Kind == KindComment && elems[i+1].tok.Line == e.tok.Line {
+				i++
+			}
+			parts = append(parts, elems[start:i+1])
+			start = i + 1
+		}
+	}
+	if start < len(elems) {
+		parts = append(parts, elems[start:])
+	}
+	return parts
+}
+
+// clauseKeywords is the sequences of keywords which start clauses.
+// Longer sequences come first.
+var clauseKeywords = [][]string{
+	{"with", "recursive"}, {"with"},
+	{"select", "distinct"}, {"select", "all"}, {"select"},
+	{"insert", "into"}, {"delete", "from"}, {"update"}, {"values"}, {"set"},
+	{"from"}, {"where"}, {"group", "by"}, {"having"}, {"window"},
+	{"order", "by"}, {"limit"}, {"offset"}, {"fetch"},
+	{"on", "conflict"}, {"returning"},
+	{"union", "all"}, {"union"}, {"intersect"}, {"except"},
+	{"natural", "left", "outer", "join"}, {"natural", "right", "outer", "join"},
+	{"natural", "full", "outer", "join"}, {"natural", "left", "join"},
+	{"natural", "right", "join"}, {"natural", "full", "join"},
+	{"natural", "inner", "join"}, {"natural", "join"},
+	{"left", "outer", "join"}, {"right", "outer", "join"}, {"full", "outer", "join"},
+	{"left", "join"}, {"right", "join"}, {"full", "join"},
+	{"inner", "join"}, {"cross", "join"}, {"join"},
+}
+
+// listClauses is the clauses whose bodies are lists filled in lines.
+var listClauses = map[string]bool{
+	"select": true, "select distinct": true, "select all": true,
+	"from": true, "group by": true, "order by": true, "set": true,
+	"values": true, "returning": true,
+}
+
+// conditionClauses is the clauses whose bodies are conditions.
+var conditionClauses = map[string]bool{
+	"where": true, "having": true,
+}
+
+// clauseAt returns the keywords of the clause starting at the element,
+// if any.
+func clauseAt(elems []elem) []string {
+	for _, kws := range clauseKeywords {
+		if len(kws) > len(elems) {
+			continue
+		}
+		matched := true
+		for i, kw := range kws {
+			matched = matched && isKeyword(elems[i], kw)
+		}
+		if matched {
+			return kws
+		}
+	}
+	return nil
+}
+
+func isKeyword(e elem, kw string) bool {
+	return e.tok.Kind == KindKeyword && e.inner == nil && strings.EqualFold(e.tok.Text, kw)
+}
+
+type layout struct {
+	opts Options
+}
+
+// statement lays out the clauses of the statement, which are broken into
+// lines if they don't fit. It reports whether the statement has a line
+// comment, which requires the enclosing groups to be broken.
+func (l *layout) statement(elems []elem) (p.Doc, bool) {
+	clauses := []p.Doc{}
+	hard := false
+	for i := 0; i < len(elems); {
+		kws := clauseAt(elems[i:])
+		j := i + len(kws)
+		for j < len(elems) && (clauseAt(elems[j:]) == nil || isKeyword(elems[j-1], "do")) {
+			// DO UPDATE of ON CONFLICT is not a clause.
+			j++
+		}
+		doc, h := l.clause(elems[i:i+len(kws)], elems[i+len(kws):j])
+		clauses = append(clauses, doc)
+		hard = hard || h
+		i = j
+	}
+	doc := p.Intercalate(p.Line(), clauses)
+	if hard {
+		return doc, true
+	}
+	return p.Group(doc), false
+}
+
+// clause lays out the keywords and the body of the clause. The body is
+// indented if it's broken into lines.
+func (l *layout) clause(kws []elem, body []elem) (p.Doc, bool) {
+	ds := []p.Doc{}
+	names := []string{}
+	for _, kw := range kws {
+		ds = append(ds, l.token(kw.tok))
+		names = append(names, strings.ToLower(kw.tok.Text))
+	}
+	head := p.Intercalate(p.Text(" "), ds)
+	if len(body) == 0 {
+		return head, false
+	}
+	if len(kws) == 0 {
+		// the elements before the first clause, such as comments.
+		return l.expr(body, false)
+	}
+	name := strings.Join(names, " ")
+	var doc p.Doc
+	var hard bool
+	switch {
+	case listClauses[name]:
+		doc, hard = l.list(body, false)
+	case conditionClauses[name]:
+		doc, hard = l.condition(body)
+	case name == "with" || name == "with recursive":
+		// the common table expressions are aligned with the statement.
+		doc, hard = l.list(body, true)
+		return p.Concat([]p.Doc{head, p.Text(" "), doc}), hard
+	case strings.HasSuffix(name, "join"):
+		// the condition is indented by join.
+		doc, hard = l.join(body)
+		return p.Concat([]p.Doc{head, p.Text(" "), doc}), hard
+	default:
+		doc, hard = l.expr(body, name == "insert into")
+	}
+	return p.Concat([]p.Doc{head, p.Text(" "), p.Nest(l.opts.Indent, doc)}), hard
+}
+
+// list lays out the comma-separated elements. The items are filled in
+// lines, or written on separate lines if lines is set.
+func (l *layout) list(elems []elem, lines bool) (p.Doc, bool) {
+	items := split(elems, ",")
+	docs := make([]p.Doc, len(items))
+	hard := false
+	for i, item := range items {
+		var h bool
+		docs[i], h = l.expr(item, false)
+		hard = hard || h
+	}
+	if hard || lines {
+		return p.Intercalate(p.Line(), docs), hard
+	}
+	return p.Fill(p.Line(), docs), false
+}
+
+// condition lays out the condition, which is broken before the top-level
+// AND and OR if it doesn't fit.
+func (l *layout) condition(elems []elem) (p.Doc, bool) {
+	ds := []p.Doc{}
+	hard := false
+	start := 0
+	between := false
+	flush := func(end int) {
+		doc, h := l.expr(elems[start:end], false)
+		ds = append(ds, doc)
+		hard = hard || h
+	}
+	for i, e := range elems {
+		switch {
+		case isKeyword(e, "between"):
+			between = true
+		case isKeyword(e, "and") && between:
+			// the AND of BETWEEN x AND y.
+			between = false
+		case (isKeyword(e, "and") || isKeyword(e, "or")) && i > start:
+			flush(i)
+			ds = append(ds, p.Line())
+			start = i
+		}
+	}
+	flush(len(elems))
+	if hard {
+		return p.Concat(ds), true
+	}
+	return p.Group(p.Concat(ds)), false
+}
+
+// join lays out the table of the join, followed by the ON or USING
+// condition, which is broken into the next line if it doesn't fit.
+func (l *layout) join(elems []elem) (p.Doc, bool) {
+	for i, e := range elems {
+		if i > 0 && (isKeyword(e, "on") || isKeyword(e, "using")) {
+			table, h1 := l.expr(elems[:i], false)
+			cond, h2 := l.condition(elems[i+1:])
+			doc := p.Concat([]p.Doc{
+				table,
+				p.Nest(l.opts.Indent, p.Concat([]p.Doc{p.Line(), l.token(e.tok), p.Text(" "), cond})),
+			})
+			if h1 || h2 {
+				return doc, true
+			}
+			return p.Group(doc), false
+		}
+	}
+	return l.expr(elems, false)
+}
+
+// expr lays out the elements separated by spaces where they are needed.
+// If spaceParen is set, a space is written between an identifier and
+// the following parentheses, which are otherwise the arguments of
+// a function.
+func (l *layout) expr(elems []elem, spaceParen bool) (p.Doc, bool) {
+	ds := []p.Doc{}
+	hard := false
+	var prev *elem
+	for i := range elems {
+		e := &elems[i]
+		if prev != nil {
+			switch {
+			case isLineComment(prev.tok):
+				ds = append(ds, p.LineBreak())
+			case l.spaced(prev, e, spaceParen, i > 1 && elems[i-2].isOperand()):
+				ds = append(ds, p.Text(" "))
+			}
+		}
+		var doc p.Doc
+		var h bool
+		if e.inner != nil {
+			doc, h = l.parens(e.inner)
+		} else {
+			doc, h = l.token(e.tok), isLineComment(e.tok) || strings.Contains(e.tok.Text, "\n")
+		}
+		ds = append(ds, doc)
+		hard = hard || h
+		prev = e
+	}
+	return p.Concat(ds), hard
+}
+
+// spaced reports whether a space is written between the elements.
+// operand reports whether the element before prev is an operand, to tell
+// whether prev is a unary operator.
+func (l *layout) spaced(prev *elem, next *elem, spaceParen bool, operand bool) bool {
+	a, b := prev.tok.Text, next.tok.Text
+	switch {
+	case prev.inner == nil && (a == "." || a == "::" || a == "["),
+		next.inner == nil && (b == "." || b == "::" || b == "," || b == ";" || b == "[" || b == "]"):
+		return false
+	case next.inner != nil:
+		// a function call, or a keyword followed by parentheses.
+		return spaceParen || !(prev.inner == nil && (prev.tok.Kind == KindIdent || a == "]"))
+	case prev.inner == nil && (a == "-" || a == "+" || a == "~") && !operand:
+		return false
+	}
+	return true
+}
+
+// isOperand reports whether the element is an operand of operators.
+func (e *elem) isOperand() bool {
+	if e.inner != nil {
+		return true
+	}
+	switch e.tok.Kind {
+	case KindOperator:
+		return e.tok.Text == "]" || e.tok.Text == "*"
+	case KindKeyword:
+		switch strings.ToLower(e.tok.Text) {
+		case "null", "true", "false", "end":
+			return true
+		}
+		return false
+	case KindComment:
+		return false
+	}
+	return true
+}
+
+// parens lays out the elements in parentheses. A subquery is laid out as
+// a statement, and a list is filled in lines.
+func (l *layout) parens(elems []elem) (p.Doc, bool) {
+	if len(elems) == 0 {
+		return p.Text("()"), false
+	}
+	var body p.Doc
+	var hard bool
+	switch {
+	case isKeyword(elems[0], "select") || isKeyword(elems[0], "with") || isKeyword(elems[0], "values"):
+		body, hard = l.statement(elems)
+	case len(split(elems, ",")) > 1:
+		body, hard = l.list(elems, false)
+	case hasConnective(elems):
+		body, hard = l.condition(elems)
+	default:
+		body, hard = l.expr(elems, false)
+		if isLineComment(elems[len(elems)-1].tok) {
+			// the closing parenthesis must not be in the comment.
+			return p.Concat([]p.Doc{p.Text("("), body, p.LineBreak(), p.Text(")")}), true
+		}
+		return p.Concat([]p.Doc{p.Text("("), body, p.Text(")")}), hard
+	}
+	if hard {
+		return p.Concat([]p.Doc{
+			p.Text("("),
+			p.Nest(l.opts.Indent, p.Concat([]p.Doc{p.LineBreak(), body})),
+			p.LineBreak(),
+			p.Text(")"),
+		}), true
+	}
+	return p.TightBracketBy(p.Text("("), p.Text(")"), body, l.opts.Indent), false
+}
+
+func hasConnective(elems []elem) bool {
+	for _, e := range elems {
+		if isKeyword(e, "and") || isKeyword(e, "or") {
+			return true
+		}
+	}
+	return false
+}
+
+func isLineComment(tok Token) bool {
+	return tok.Kind == KindComment && strings.HasPrefix(tok.Text, "--")
+}
+
+// token writes the token, casing it if it's a keyword.
+func (l *layout) token(tok Token) p.Doc {
+	if tok.Kind == KindKeyword {
+		switch l.opts.Keywords {
+		case CaseUpper:
+			return p.Text(strings.ToUpper(tok.Text))
+		case CaseLower:
+			return p.Text(strings.ToLower(tok.Text))
+		}
+	}
+	return p.Text(tok.Text)
+}
@@ -0,0 +1,209 @@
+package sql
+
+import (
+	"fmt"
+	"testing"
+)
+
+func format(t *testing.T, src string, width int, opts Options) string {
+	t.Helper()
+	actual, err := Format(src, width, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return actual
+}
+
+const query = `with recent as (select * from orders where created_at > now() - interval '7 days')
+select u.name, sum(r.amount) as total from users u
+left join recent r on r.user_id = u.id and r.amount > 0
+where u.id in (select user_id from admins) and u.age between 18 and 65
+group by u.name order by total desc limit 10`
+
+func TestFormat(t *testing.T) {
+	tests := []struct {
+		width    int
+		expected string
+	}{
+		{400, "WITH recent AS (SELECT * FROM orders WHERE created_at > now() - INTERVAL '7 days') SELECT u.name, sum(r.amount) AS total FROM users u LEFT JOIN recent r ON r.user_id = u.id AND r.amount > 0 WHERE u.id IN (SELECT user_id FROM admins) AND u.age BETWEEN 18 AND 65 GROUP BY u.name ORDER BY total DESC LIMIT 10"},
+		{80, `WITH recent AS (
+  SELECT * FROM orders WHERE created_at > now() - INTERVAL '7 days'
+)
+SELECT u.name, sum(r.amount) AS total
+FROM users u
+LEFT JOIN recent r ON r.user_id = u.id AND r.amount > 0
+WHERE u.id IN (SELECT user_id FROM admins) AND u.age BETWEEN 18 AND 65
+GROUP BY u.name
+ORDER BY total DESC
+LIMIT 10`},
+		{30, `WITH recent AS (
+  SELECT *
+  FROM orders
+  WHERE created_at > now() - INTERVAL '7 days'
+)
+SELECT u.name,
+  sum(r.amount) AS total
+FROM users u
+LEFT JOIN recent r
+  ON r.user_id = u.id
+  AND r.amount > 0
+WHERE u.id IN (
+    SELECT user_id FROM admins
+  )
+  AND u.age BETWEEN 18 AND 65
+GROUP BY u.name
+ORDER BY total DESC
+LIMIT 10`},
+	}
+	for _, tt := range tests {
+		if actual := format(t, query, tt.width, Options{Keywords: CaseUpper}); actual != tt.expected {
+			t.Errorf("expected: %v, actual: %v", tt.expected, actual)
+		}
+	}
+}
+
+func TestFormatIdempotent(t *testing.T) {
+	for _, width := range []int{20, 40, 80} {
+		once := format(t, query, width, Options{})
+		if twice := format(t, once, width, Options{}); twice != once {
+			t.Errorf("expected: %v, actual: %v", once, twice)
+		}
+	}
+}
+
+func TestFormatKeywords(t *testing.T) {
+	tests := []struct {
+		keywords Case
+		expected string
+	}{
+		{CasePreserve, `Select "Name", count(*) From t WHERE x Is Not Null`},
+		{CaseUpper, `SELECT "Name", count(*) FROM t WHERE x IS NOT NULL`},
+		{CaseLower, `select "Name", count(*) from t where x is not null`},
+	}
+	for _, tt := range tests {
+		actual := format(t, `Select "Name", count(*) From t WHERE x Is Not Null`, 80, Options{Keywords: tt.keywords})
+		if actual != tt.expected {
+			t.Errorf("expected: %v, actual: %v", tt.expected, actual)
+		}
+	}
+}
+
+func TestFormatKeywordPosition(t *testing.T) {
+	tests := []struct {
+		src      string
+		expected string
+	}{
+		{"select first, last, offset from t", "SELECT first, last, offset FROM t"},
+		{"select a from t order by a desc nulls last offset 5 fetch next 3 rows only", "SELECT a FROM t ORDER BY a DESC NULLS LAST OFFSET 5 FETCH NEXT 3 ROWS ONLY"},
+		{"select a from t offset $1", "SELECT a FROM t OFFSET $1"},
+		{"insert into t (a) values (1) on conflict do nothing", "INSERT INTO t (a) VALUES (1) ON CONFLICT DO NOTHING"},
+		{"with x as materialized (select 1) select * from x", "WITH x AS MATERIALIZED (SELECT 1) SELECT * FROM x"},
+	}
+	for _, tt := range tests {
+		if actual := format(t, tt.src, 200, Options{Keywords: CaseUpper}); actual != tt.expected {
+			t.Errorf("expected: %v, actual: %v", tt.expected, actual)
+		}
+	}
+	// offset is a column, not a clause.
+	if actual := format(t, "select offset from t", 10, Options{}); actual != "select offset\nfrom t" {
+		t.Errorf("expected: %v, actual: %v", "select offset\nfrom t", actual)
+	}
+}
+
+func TestFormatInsert(t *testing.T) {
+	expected := `INSERT INTO users (id, name, email)
+VALUES (1, 'a', 'a@example.com'), (2, 'b''c', NULL)
+ON CONFLICT (id) DO UPDATE
+SET name = excluded.name, email = excluded.email
+RETURNING id;
+UPDATE users SET name = $1 WHERE id = $2;`
+	src := `insert into users(id,name,email) values(1,'a','a@example.com'),(2,'b''c',null)
+on conflict(id) do update set name=excluded.name,email=excluded.email returning id;
+update users set name=$1 where id=$2;`
+	if actual := format(t, src, 60, Options{Keywords: CaseUpper}); actual != expected {
+		t.Errorf("expected: %v, actual: %v", expected, actual)
+	}
+}
+
+func TestFormatSpacing(t *testing.T) {
+	tests := []struct {
+		src      string
+		expected string
+	}{
+		{"select a-1,-1,t.*,x::int,arr[1],f(-a)", "select a - 1, -1, t.*, x::int, arr[1], f(-a)"},
+		{"select * from t where a=(b+c)*2 and not exists(select 1)", "select * from t where a = (b + c) * 2 and not exists (select 1)"},
+		{"select count(*) filter(where x>0) over(partition by y order by z)", "select count(*) filter (where x > 0) over (partition by y order by z)"},
+	}
+	for _, tt := range tests {
+		if actual := format(t, tt.src, 80, Options{}); actual != tt.expected {
+			t.Errorf("expected: %v, actual: %v", tt.expected, actual)
+		}
+	}
+}
+
+func TestFormatComments(t *testing.T) {
+	expected := `SELECT a, -- the first
+  b
+FROM t /* the table */
+WHERE x = 1 -- only one
+  AND y = 2`
+	src := "SELECT a, -- the first\nb FROM t /* the table */ WHERE x = 1 -- only one\nAND y = 2"
+	if actual := format(t, src, 80, Options{}); actual != expected {
+		t.Errorf("expected: %v, actual: %v", expected, actual)
+	}
+	expected = `-- the statements
+SELECT 1; -- one
+SELECT 2 -- two
+;`
+	src = "-- the statements\nSELECT 1; -- one\nSELECT 2 -- two\n;"
+	if actual := format(t, src, 80, Options{}); actual != expected {
+		t.Errorf("expected: %v, actual: %v", expected, actual)
+	}
+	// the closing parentheses are not in the comments.
+	tests := []struct {
+		src      string
+		expected string
+	}{
+		{"SELECT f(a -- c\n)", "SELECT f(a -- c\n  )"},
+		{"SELECT * FROM t WHERE (a = 1 -- c\n)", "SELECT *\nFROM t\nWHERE (a = 1 -- c\n  )"},
+	}
+	for _, tt := range tests {
+		actual := format(t, tt.src, 80, Options{})
+		if actual != tt.expected {
+			t.Errorf("expected: %v, actual: %v", tt.expected, actual)
+		}
+		if twice := format(t, actual, 80, Options{}); twice != actual {
+			t.Errorf("expected: %v, actual: %v", actual, twice)
+		}
+	}
+}
+
+func TestFormatError(t *testing.T) {
+	tests := []struct {
+		src      string
+		expected string
+	}{
+		{"select (a", "sql: 1:8: unclosed '('"},
+		{"select a)", "sql: 1:9: unexpected ')'"},
+		{"select 'a", "sql: 1:10: unterminated string"},
+	}
+	for _, tt := range tests {
+		_, err := Format(tt.src, 80, Options{})
+		if err == nil || err.Error() != tt.expected {
+			t.Errorf("expected: %v, actual: %v", tt.expected, err)
+		}
+	}
+}
+
+func ExampleFormat() {
+	out, err := Format("select id, name from users where active and age >= 18 order by name", 40, Options{Keywords: CaseUpper})
+	if err != nil {
+		panic(err)
+	}
+	fmt.Println(out)
+	// Output:
+	// SELECT id, name
+	// FROM users
+	// WHERE active AND age >= 18
+	// ORDER BY name
+}
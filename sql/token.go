@@ -0,0 +1,319 @@
+package sql
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Kind is the kind of a Token.
+type Kind int
+
+// Kinds of Token.
+const (
+	KindKeyword Kind = iota
+	KindIdent
+	KindString
+	KindNumber
+	KindParam
+	KindOperator
+	KindComment
+)
+
+// Token is a token of SQL.
+type Token struct {
+	Kind Kind
+	// Text is the text of the token as it is written, including the quotes
+	// of strings and quoted identifiers, and the delimiters of comments.
+	Text string
+	// Line and Col are the 1-based position of the token.
+	Line int
+	Col  int
+}
+
+// keywords is the reserved words, which are keywords wherever they are.
+// The keywords are cased by Options.Keywords and start clauses.
+var keywords = map[string]bool{
+	"all": true, "and": true, "any": true, "as": true, "asc": true,
+	"between": true, "by": true, "case": true, "cross": true,
+	"default": true, "delete": true, "desc": true, "distinct": true,
+	"do": true, "else": true, "end": true, "except": true, "exists": true,
+	"false": true, "fetch": true, "filter": true, "from": true,
+	"full": true, "group": true, "having": true, "ilike": true, "in": true,
+	"inner": true, "insert": true, "intersect": true, "interval": true,
+	"into": true, "is": true, "join": true, "lateral": true, "left": true,
+	"like": true, "limit": true, "natural": true, "not": true,
+	"null": true, "on": true, "only": true, "or": true, "order": true,
+	"outer": true, "over": true, "partition": true, "recursive": true,
+	"returning": true, "right": true, "rows": true, "select": true,
+	"set": true, "some": true, "then": true, "true": true, "union": true,
+	"update": true, "using": true, "values": true, "when": true,
+	"where": true, "window": true, "with": true,
+}
+
+// contextualKeywords is the words which are keywords only in keyword
+// position, and are identifiers such as the names of columns elsewhere.
+// They map to whether the word is in keyword position, given the tokens
+// before and after it, which are nil at the ends.
+var contextualKeywords = map[string]func(prev, next *Token) bool{
+	// NULLS FIRST, NULLS LAST and FETCH FIRST.
+	"first": func(prev, next *Token) bool { return isWord(prev, "nulls", "fetch") },
+	"last":  func(prev, next *Token) bool { return isWord(prev, "nulls") },
+	"next":  func(prev, next *Token) bool { return isWord(prev, "fetch") },
+	"nulls": func(prev, next *Token) bool { return ends(prev) && isWord(next, "first", "last") },
+	// the OFFSET clause follows an expression, and is followed by one.
+	"offset":   func(prev, next *Token) bool { return ends(prev) && starts(next) },
+	"conflict": func(prev, next *Token) bool { return isWord(prev, "on") },
+	"nothing":  func(prev, next *Token) bool { return isWord(prev, "do") },
+	// AS MATERIALIZED and AS NOT MATERIALIZED of common table expressions.
+	"materialized": func(prev, next *Token) bool {
+		return isWord(prev, "as", "not") && next != nil && next.Text == "("
+	},
+}
+
+// isWord reports whether the token is one of the words.
+func isWord(tok *Token, words ...string) bool {
+	if tok == nil || tok.Kind != KindKeyword && tok.Kind != KindIdent {
+		return false
+	}
+	for _, w := range words {
+		if strings.EqualFold(tok.Text, w) {
+			return true
+		}
+	}
+	return false
+}
+
+// ends reports whether the token can end an expression.
+func ends(tok *Token) bool {
+	if tok == nil {
+		return false
+	}
+	switch tok.Kind {
+	case KindIdent, KindString, KindNumber, KindParam:
+		return true
+	case KindOperator:
+		return tok.Text == ")" || tok.Text == "]"
+	case KindKeyword:
+		return isWord(tok, "null", "true", "false", "end", "asc", "desc", "first", "last")
+	}
+	return false
+}
+
+// starts reports whether the token can start an expression.
+func starts(tok *Token) bool {
+	if tok == nil {
+		return false
+	}
+	switch tok.Kind {
+	case KindIdent, KindString, KindNumber, KindParam:
+		return true
+	case KindOperator:
+		return tok.Text == "(" || tok.Text == "-" || tok.Text == "+"
+	}
+	return false
+}
+
+// operators is the operators of more than one character.
+var operators = []string{"->>", "<>", "<=", ">=", "!=", "||", "::", "->", "=>"}
+
+// Tokenize splits the SQL into tokens. Spaces are skipped, and comments
+// are kept as tokens. The reserved words are keywords, and the other
+// words which can be keywords are keywords only in keyword position,
+// such as OFFSET after an expression; `SELECT offset FROM t` selects the
+// column offset.
+func Tokenize(src string) ([]Token, error) {
+	s := &scanner{src: src, line: 1, col: 1}
+	toks := []Token{}
+	for {
+		s.skipSpaces()
+		if s.pos >= len(s.src) {
+			break
+		}
+		tok, err := s.token()
+		if err != nil {
+			return nil, err
+		}
+		toks = append(toks, tok)
+	}
+	// the tokens around the words, skipping comments.
+	var prev *Token
+	for i := range toks {
+		tok := &toks[i]
+		if tok.Kind == KindComment {
+			continue
+		}
+		if inPosition, ok := contextualKeywords[strings.ToLower(tok.Text)]; ok && tok.Kind == KindIdent {
+			var next *Token
+			for j := i + 1; j < len(toks); j++ {
+				if toks[j].Kind != KindComment {
+					next = &toks[j]
+					break
+				}
+			}
+			if inPosition(prev, next) {
+				tok.Kind = KindKeyword
+			}
+		}
+		prev = tok
+	}
+	return toks, nil
+}
+
+type scanner struct {
+	src  string
+	pos  int
+	line int
+	col  int
+}
+
+func (s *scanner) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("sql: %d:%d: %s", s.line, s.col, fmt.Sprintf(format, args...))
+}
+
+func (s *scanner) peek(i int) byte {
+	if s.pos+i < len(s.src) {
+		return s.src[s.pos+i]
+	}
+	return 0
+}
+
+func (s *scanner) advance(n int) {
+	for _, c := range s.src[s.pos : s.pos+n] {
+		if c == '\n' {
+			s.line++
+			s.col = 1
+		} else {
+			s.col++
+		}
+	}
+	s.pos += n
+}
+
+func (s *scanner) skipSpaces() {
+	for s.pos < len(s.src) && strings.IndexByte(" \t\r\n", s.src[s.pos]) >= 0 {
+		s.advance(1)
+	}
+}
+
+func (s *scanner) token() (Token, error) {
+	start, line, col := s.pos, s.line, s.col
+	kind, err := s.scan()
+	if err != nil {
+		return Token{}, err
+	}
+	tok := Token{Kind: kind, Text: s.src[start:s.pos], Line: line, Col: col}
+	if kind == KindIdent && keywords[strings.ToLower(tok.Text)] {
+		tok.Kind = KindKeyword
+	}
+	return tok, nil
+}
+
+// scan advances the scanner over the next token, and returns its kind.
+func (s *scanner) scan() (Kind, error) {
+	c := s.src[s.pos]
+	switch {
+	case c == '-' && s.peek(1) == '-':
+		n := strings.IndexByte(s.src[s.pos:], '\n')
+		if n < 0 {
+			n = len(s.src) - s.pos
+		}
+		s.advance(len(strings.TrimRight(s.src[s.pos:s.pos+n], " \t\r")))
+		return KindComment, nil
+	case c == '/' && s.peek(1) == '*':
+		n := strings.Index(s.src[s.pos+2:], "*/")
+		if n < 0 {
+			return 0, s.errorf("unterminated comment")
+		}
+		s.advance(n + 4)
+		return KindComment, nil
+	case c == '\'':
+		return KindString, s.quoted('\'', "string")
+	case c == '"' || c == '`':
+		return KindIdent, s.quoted(c, "identifier")
+	case strings.IndexByte("NnEeXxBb", c) >= 0 && s.peek(1) == '\'':
+		// a prefixed string such as N'...' or E'...'.
+		s.advance(1)
+		return KindString, s.quoted('\'', "string")
+	case c >= '0' && c <= '9' || c == '.' && s.peek(1) >= '0' && s.peek(1) <= '9':
+		s.number()
+		return KindNumber, nil
+	case c == '?':
+		s.advance(1)
+		for s.pos < len(s.src) && s.src[s.pos] >= '0' && s.src[s.pos] <= '9' {
+			s.advance(1)
+		}
+		return KindParam, nil
+	case (c == '$' || c == '@' || c == ':' && s.peek(1) != ':') && s.isWordAt(s.pos+1):
+		s.advance(1)
+		s.word()
+		return KindParam, nil
+	case s.isWordAt(s.pos):
+		s.word()
+		return KindIdent, nil
+	}
+	for _, op := range operators {
+		if strings.HasPrefix(s.src[s.pos:], op) {
+			s.advance(len(op))
+			return KindOperator, nil
+		}
+	}
+	if strings.IndexByte("(),;.*=<>+-/%~^&|[]!:", c) >= 0 {
+		s.advance(1)
+		return KindOperator, nil
+	}
+	r, _ := utf8.DecodeRuneInString(s.src[s.pos:])
+	return 0, s.errorf("unexpected %q", r)
+}
+
+// quoted advances over the text quoted by q, where q is escaped by
+// doubling it.
+func (s *scanner) quoted(q byte, what string) error {
+	s.advance(1)
+	for {
+		n := strings.IndexByte(s.src[s.pos:], q)
+		if n < 0 {
+			s.advance(len(s.src) - s.pos)
+			return s.errorf("unterminated %s", what)
+		}
+		s.advance(n + 1)
+		if s.peek(0) != q {
+			return nil
+		}
+		s.advance(1)
+	}
+}
+
+func (s *scanner) number() {
+	for s.pos < len(s.src) {
+		c := s.src[s.pos]
+		switch {
+		case c >= '0' && c <= '9' || c == '.' || c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z':
+			// digits, hexadecimals and exponents.
+			s.advance(1)
+		case (c == '+' || c == '-') && (s.src[s.pos-1] == 'e' || s.src[s.pos-1] == 'E'):
+			s.advance(1)
+		default:
+			return
+		}
+	}
+}
+
+func (s *scanner) isWordAt(pos int) bool {
+	if pos >= len(s.src) {
+		return false
+	}
+	r, _ := utf8.DecodeRuneInString(s.src[pos:])
+	return r == '_' || unicode.IsLetter(r) || r >= '0' && r <= '9' && pos > s.pos
+}
+
+func (s *scanner) word() {
+	for s.pos < len(s.src) {
+		r, n := utf8.DecodeRuneInString(s.src[s.pos:])
+		if r != '_' && r != '$' && !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			return
+		}
+		s.advance(n)
+	}
+}
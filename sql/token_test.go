@@ -0,0 +1,76 @@
+package sql
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTokenize(t *testing.T) {
+	toks, err := Tokenize("SELECT \"a\"\"b\", N'x''y' -- c\n/* d */ FROM t WHERE n >= 1.5e-3 AND id = $1 OR k = :key::text")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []Token{
+		{Kind: KindKeyword, Text: "SELECT", Line: 1, Col: 1},
+		{Kind: KindIdent, Text: `"a""b"`, Line: 1, Col: 8},
+		{Kind: KindOperator, Text: ",", Line: 1, Col: 14},
+		{Kind: KindString, Text: "N'x''y'", Line: 1, Col: 16},
+		{Kind: KindComment, Text: "-- c", Line: 1, Col: 24},
+		{Kind: KindComment, Text: "/* d */", Line: 2, Col: 1},
+		{Kind: KindKeyword, Text: "FROM", Line: 2, Col: 9},
+		{Kind: KindIdent, Text: "t", Line: 2, Col: 14},
+		{Kind: KindKeyword, Text: "WHERE", Line: 2, Col: 16},
+		{Kind: KindIdent, Text: "n", Line: 2, Col: 22},
+		{Kind: KindOperator, Text: ">=", Line: 2, Col: 24},
+		{Kind: KindNumber, Text: "1.5e-3", Line: 2, Col: 27},
+		{Kind: KindKeyword, Text: "AND", Line: 2, Col: 34},
+		{Kind: KindIdent, Text: "id", Line: 2, Col: 38},
+		{Kind: KindOperator, Text: "=", Line: 2, Col: 41},
+		{Kind: KindParam, Text: "$1", Line: 2, Col: 43},
+		{Kind: KindKeyword, Text: "OR", Line: 2, Col: 46},
+		{Kind: KindIdent, Text: "k", Line: 2, Col: 49},
+		{Kind: KindOperator, Text: "=", Line: 2, Col: 51},
+		{Kind: KindParam, Text: ":key", Line: 2, Col: 53},
+		{Kind: KindOperator, Text: "::", Line: 2, Col: 57},
+		{Kind: KindIdent, Text: "text", Line: 2, Col: 59},
+	}
+	if !reflect.DeepEqual(toks, expected) {
+		t.Errorf("expected: %v, actual: %v", expected, toks)
+	}
+}
+
+func TestTokenizeContextual(t *testing.T) {
+	toks, err := Tokenize("SELECT offset, first FROM t ORDER BY a NULLS FIRST OFFSET 1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	kinds := []Kind{}
+	for _, tok := range toks {
+		kinds = append(kinds, tok.Kind)
+	}
+	expected := []Kind{
+		KindKeyword, KindIdent, KindOperator, KindIdent, KindKeyword, KindIdent,
+		KindKeyword, KindKeyword, KindIdent, KindKeyword, KindKeyword, KindKeyword, KindNumber,
+	}
+	if !reflect.DeepEqual(kinds, expected) {
+		t.Errorf("expected: %v, actual: %v", expected, kinds)
+	}
+}
+
+func TestTokenizeError(t *testing.T) {
+	tests := []struct {
+		src      string
+		expected string
+	}{
+		{"select 'a", "sql: 1:10: unterminated string"},
+		{"select \"a", "sql: 1:10: unterminated identifier"},
+		{"/* a", "sql: 1:1: unterminated comment"},
+		{"select #", "sql: 1:8: unexpected '#'"},
+	}
+	for _, tt := range tests {
+		_, err := Tokenize(tt.src)
+		if err == nil || err.Error() != tt.expected {
+			t.Errorf("expected: %v, actual: %v", tt.expected, err)
+		}
+	}
+}
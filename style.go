@@ -0,0 +1,88 @@
+package prettier
+
+import (
+	"strings"
+)
+
+// Style is an annotation value which decorates the annotated document
+// in the output of the renderers supporting styles, such as PrettyLaTeX
+// and PrettyTypst. Nested styles are combined.
+type Style struct {
+	Bold      bool `json:"bold,omitempty"`
+	Italic    bool `json:"italic,omitempty"`
+	Underline bool `json:"underline,omitempty"`
+	// Color is either the name of a color, or a hex code like "#ff0000".
+	// An empty string means the color is unchanged.
+	Color string `json:"color,omitempty"`
+}
+
+// styledRun is a string rendered with the same styles,
+// from the outermost style to the innermost one.
+type styledRun struct {
+	str    string
+	styles []Style
+}
+
+func sameStyles(a []Style, b []Style) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// styledLines splits the layout into lines of styled runs.
+// Indentation is never styled.
+func styledLines(c chunk) [][]styledRun {
+	lines := [][]styledRun{{}}
+	annotations := []interface{}{}
+	styles := []Style{}
+	add := func(str string, styles []Style) {
+		if str == "" {
+			return
+		}
+		line := lines[len(lines)-1]
+		if len(line) > 0 && sameStyles(line[len(line)-1].styles, styles) {
+			line[len(line)-1].str += str
+			return
+		}
+		lines[len(lines)-1] = append(line, styledRun{str: str, styles: styles})
+	}
+	for {
+		switch v := c.(type) {
+		case *textChunk:
+			add(v.str, styles)
+			c = v.c
+		case *lineChunk:
+			lines = append(lines, []styledRun{})
+			add(strings.Repeat(" ", int(v.indent)), nil)
+			c = v.c
+		case *annotationChunk:
+			if v.start {
+				annotations = append(annotations, v.value)
+			} else {
+				annotations = annotations[:len(annotations)-1]
+			}
+			// copy so that runs already added are not modified.
+			styles = []Style{}
+			for _, a := range annotations {
+				if s, ok := a.(Style); ok {
+					styles = append(styles, s)
+				}
+			}
+			c = v.c
+		case *cursorChunk:
+			c = v.c
+		case *traceChunk:
+			c = v.c
+		case *lazyChunk:
+			c = v.force()
+		default:
+			return lines
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package prettier
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Decision is a choice between the layouts of a union, such as the
+// flat and the broken layouts of a Group or of a part of a Fill followed
+// by the separator, made by the renderer.
+type Decision struct {
+	// Position is where the union starts in the output.
+	Position Position
+	// Annotations are the values of the annotations enclosing the union,
+	// from the outermost one.
+	Annotations []interface{}
+	// Column is the length of the line before the union, and Remaining
+	// is the width left for the union (which may be negative). They are
+	// counted in the units of the width, which are the lengths of the
+	// texts (runes unless given by TextWithLength), unlike the bytes of
+	// Position.Column.
+	Column    int
+	Remaining int
+	// Flat reports whether the flat layout was chosen.
+	Flat bool
+	// Overflow is the text that made the flat layout exceed the width.
+	// It is empty if the flat layout was chosen, or if the flat layout was
+	// not tried since no width was left.
+	Overflow string
+}
+
+func (d Decision) String() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%d:%d", d.Position.Line+1, d.Position.Column+1)
+	if len(d.Annotations) > 0 {
+		fmt.Fprintf(&sb, " %v", d.Annotations)
+	}
+	if d.Flat {
+		fmt.Fprintf(&sb, ": flat, fits in the remaining width %d at column %d", d.Remaining, d.Column)
+	} else if d.Overflow != "" {
+		fmt.Fprintf(&sb, ": broken, %q exceeds the remaining width %d at column %d", d.Overflow, d.Remaining, d.Column)
+	} else {
+		fmt.Fprintf(&sb, ": broken, no width remains at column %d", d.Column)
+	}
+	return sb.String()
+}
+
+// RenderTrace renders the given Doc in the same way as Render, and
+// additionally records the decisions made for the layout in
+// Result.Decisions, to explain why the groups broke or not.
+func RenderTrace(width int, doc Doc) *Result {
+	p := &printer{width: width, trace: true}
+	return render(p.best(uint(0), doc))
+}
+
+// TraceReport formats the decisions recorded by RenderTrace,
+// one decision per line.
+func (r *Result) TraceReport() string {
+	lines := []string{}
+	for _, d := range r.Decisions {
+		lines = append(lines, d.String())
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,73 @@
+package prettier
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestRenderTrace(t *testing.T) {
+	sep := Concat([]Doc{Text(","), Line()})
+	inner := Annotate("inner", TightBracketBy(
+		Text("["),
+		Text("]"),
+		Intercalate(sep, []Doc{Text("a"), Text("b")}),
+		uint(2),
+	))
+	doc := Annotate("outer", TightBracketBy(
+		Text("["),
+		Text("]"),
+		Intercalate(sep, []Doc{Text("foo"), inner}),
+		uint(2),
+	))
+	result := RenderTrace(10, doc)
+	if result.Output != "[\n  foo,\n  [a, b]\n]" {
+		t.Errorf("expected: %q, actual: %q", "[\n  foo,\n  [a, b]\n]", result.Output)
+	}
+	expected := []Decision{
+		{
+			Position:    Position{1, 0, 1},
+			Annotations: []interface{}{"outer"},
+			Column:      1,
+			Remaining:   9,
+			Flat:        false,
+			Overflow:    "b",
+		},
+		{
+			Position:    Position{12, 2, 3},
+			Annotations: []interface{}{"outer", "inner"},
+			Column:      3,
+			Remaining:   7,
+			Flat:        true,
+		},
+	}
+	if !reflect.DeepEqual(result.Decisions, expected) {
+		t.Errorf("expected: %#v, actual: %#v", expected, result.Decisions)
+	}
+	report := `1:2 [outer]: broken, "b" exceeds the remaining width 9 at column 1
+3:4 [outer inner]: flat, fits in the remaining width 7 at column 3`
+	if result.TraceReport() != report {
+		t.Errorf("expected: %v, actual: %v", report, result.TraceReport())
+	}
+}
+
+func TestRenderTraceNoWidth(t *testing.T) {
+	doc := Concat([]Doc{Text("abc"), Group(Concat([]Doc{Line(), Text("d")}))})
+	result := RenderTrace(2, doc)
+	expected := []Decision{
+		{
+			Position:    Position{3, 0, 3},
+			Annotations: []interface{}{},
+			Column:      3,
+			Remaining:   -1,
+		},
+	}
+	if !reflect.DeepEqual(result.Decisions, expected) {
+		t.Errorf("expected: %#v, actual: %#v", expected, result.Decisions)
+	}
+	if result.TraceReport() != "1:4: broken, no width remains at column 3" {
+		t.Errorf("unexpected report: %v", result.TraceReport())
+	}
+	if Render(2, doc).Decisions != nil {
+		t.Errorf("Render should not trace decisions")
+	}
+}
@@ -0,0 +1,57 @@
+package prettier
+
+import (
+	"strconv"
+	"strings"
+)
+
+var typstStringEscaper = strings.NewReplacer(
+	`\`, `\\`,
+	`"`, `\"`,
+)
+
+// PrettyTypst renders the given Doc in the same way as Pretty,
+// as a Typst block of raw text.
+//
+// Each line is made of `#raw` elements, and Style annotations are mapped to
+// `#text` (for Bold, Italic and Color) and `#underline`.
+func PrettyTypst(width int, doc Doc) string {
+	var sb strings.Builder
+	sb.WriteString("#block[")
+	for i, line := range styledLines(best(width, uint(0), doc)) {
+		if i > 0 {
+			sb.WriteString(" \\\n")
+		}
+		for _, run := range line {
+			s := `#raw("` + typstStringEscaper.Replace(run.str) + `")`
+			for i := len(run.styles) - 1; i >= 0; i-- {
+				s = typstStyle(run.styles[i], s)
+			}
+			sb.WriteString(s)
+		}
+	}
+	sb.WriteString("]")
+	return sb.String()
+}
+
+func typstStyle(style Style, s string) string {
+	args := []string{}
+	if style.Bold {
+		args = append(args, `weight: "bold"`)
+	}
+	if style.Italic {
+		args = append(args, `style: "italic"`)
+	}
+	if strings.HasPrefix(style.Color, "#") {
+		args = append(args, `fill: rgb(`+strconv.Quote(style.Color)+`)`)
+	} else if style.Color != "" {
+		args = append(args, `fill: `+style.Color)
+	}
+	if len(args) > 0 {
+		s = `#text(` + strings.Join(args, ", ") + `)[` + s + `]`
+	}
+	if style.Underline {
+		s = `#underline[` + s + `]`
+	}
+	return s
+}
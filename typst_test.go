@@ -0,0 +1,23 @@
+package prettier
+
+import (
+	"testing"
+)
+
+func TestPrettyTypst(t *testing.T) {
+	actual := PrettyTypst(40, styledTestDoc())
+	expected := `#block[#raw("[")#text(weight: "bold")[#raw("\\foo")]#raw(", ")#text(fill: rgb("#ff0000"))[#raw("{")]#text(fill: rgb("#ff0000"))[#text(style: "italic")[#raw("bar")]]#text(fill: rgb("#ff0000"))[#raw("}")]#raw(", 100%]")]`
+	if actual != expected {
+		t.Errorf("expected: %v, actual: %v", expected, actual)
+	}
+
+	actual = PrettyTypst(10, Concat([]Doc{
+		Text(`"a"`),
+		Nest(2, Concat([]Doc{Line(), Annotate(Style{Underline: true, Color: "blue"}, Text("b"))})),
+	}))
+	expected = `#block[#raw("\"a\"") \
+#raw("  ")#underline[#text(fill: blue)[#raw("b")]]]`
+	if actual != expected {
+		t.Errorf("expected: %v, actual: %v", expected, actual)
+	}
+}
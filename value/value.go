@@ -0,0 +1,345 @@
+/*
+Package value lays out arbitrary Go values as documents of prettier,
+in the Go syntax of composite literals like `%#v` of fmt does.
+*/
+package value
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	p "github.com/tanishiking/prettier"
+)
+
+// Doc converts the given value to a Doc, which is rendered as the Go
+// expression of the value. Structs, maps, slices and arrays are written
+// as composite literals broken into lines if they don't fit, and the keys
+// of maps are sorted.
+//
+// Values which refer to themselves are written as typed nil with
+// a `/* cycle */` comment, instead of being followed again.
+// Values which implement prettier.Prettier are laid out as their
+// PrettyDoc, values which are prettier.Doc are laid out as they are, and
+// values which implement fmt.GoStringer are written with GoString.
+func Doc(v interface{}) p.Doc {
+	d := &dumper{visiting: map[visit]bool{}}
+	if v == nil {
+		return p.Text("nil")
+	}
+	return d.doc(reflect.ValueOf(v), true, false)
+}
+
+// visit is a pointer being followed, which is a cycle if it is visited
+// again while it is being followed.
+type visit struct {
+	ptr uintptr
+	typ reflect.Type
+	len int
+}
+
+type dumper struct {
+	visiting map[visit]bool
+}
+
+var (
+	docType        = reflect.TypeOf((*p.Doc)(nil)).Elem()
+	prettierType   = reflect.TypeOf((*p.Prettier)(nil)).Elem()
+	goStringerType = reflect.TypeOf((*fmt.GoStringer)(nil)).Elem()
+)
+
+// doc converts v to a Doc. If explicit is set, the type of v is not known
+// from the context (e.g. v is in an interface), so the type is written
+// for scalars and nil. If elide is set, the type of a composite literal
+// can be elided since v is an element of a composite literal.
+func (d *dumper) doc(v reflect.Value, explicit bool, elide bool) p.Doc {
+	if v.Kind() != reflect.Interface && v.CanInterface() && (v.Kind() != reflect.Ptr || !v.IsNil()) {
+		switch {
+		case v.Type().Implements(docType):
+			return v.Interface().(p.Doc)
+		case v.Type().Implements(prettierType):
+			return v.Interface().(p.Prettier).PrettyDoc()
+		case v.Type().Implements(goStringerType):
+			return goString(v.Interface().(fmt.GoStringer).GoString())
+		}
+	}
+	switch v.Kind() {
+	case reflect.Bool:
+		return d.scalar(v, strconv.FormatBool(v.Bool()), explicit)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return d.scalar(v, strconv.FormatInt(v.Int(), 10), explicit)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		if v.Kind() == reflect.Uint8 {
+			return d.scalar(v, fmt.Sprintf("0x%02x", v.Uint()), explicit)
+		}
+		return d.scalar(v, strconv.FormatUint(v.Uint(), 10), explicit)
+	case reflect.Float32, reflect.Float64:
+		return d.float(v, explicit)
+	case reflect.Complex64, reflect.Complex128:
+		return d.scalar(v, fmt.Sprintf("%v", v.Complex()), explicit)
+	case reflect.String:
+		return d.scalar(v, strconv.Quote(v.String()), explicit)
+	case reflect.Interface:
+		if v.IsNil() {
+			return d.nilValue(v, explicit)
+		}
+		return d.doc(v.Elem(), true, false)
+	case reflect.Ptr:
+		return d.pointer(v, explicit, elide)
+	case reflect.Slice:
+		if v.IsNil() {
+			return d.nilValue(v, explicit)
+		}
+		key := visit{ptr: v.Pointer(), typ: v.Type(), len: v.Len()}
+		if d.visiting[key] {
+			return d.cycle(v)
+		}
+		d.visiting[key] = true
+		defer delete(d.visiting, key)
+		return d.list(v, elide)
+	case reflect.Array:
+		return d.list(v, elide)
+	case reflect.Map:
+		if v.IsNil() {
+			return d.nilValue(v, explicit)
+		}
+		key := visit{ptr: v.Pointer(), typ: v.Type()}
+		if d.visiting[key] {
+			return d.cycle(v)
+		}
+		d.visiting[key] = true
+		defer delete(d.visiting, key)
+		return d.mapValue(v, elide)
+	case reflect.Struct:
+		return d.structValue(v, elide)
+	default:
+		// functions, channels and unsafe pointers can't be written as
+		// literals.
+		if v.IsNil() {
+			return d.nilValue(v, explicit)
+		}
+		return p.Text(fmt.Sprintf("(%s)(%#x)", v.Type(), v.Pointer()))
+	}
+}
+
+// goString writes the result of GoString, which may be broken into lines.
+// Its lines are kept as they are, and its length is the width of the last
+// line, which is the column after it.
+func goString(s string) p.Doc {
+	if i := strings.LastIndexByte(s, '\n'); i >= 0 {
+		return p.TextWithLength(s, utf8.RuneCountInString(s[i+1:]))
+	}
+	return p.Text(s)
+}
+
+// scalar writes the literal of a scalar, converted to its type if the
+// type is not the default type of the literal.
+func (d *dumper) scalar(v reflect.Value, literal string, explicit bool) p.Doc {
+	if explicit && !isDefaultType(v.Type()) {
+		return p.Text(fmt.Sprintf("%s(%s)", v.Type(), literal))
+	}
+	return p.Text(literal)
+}
+
+func isDefaultType(t reflect.Type) bool {
+	switch t {
+	case reflect.TypeOf(false), reflect.TypeOf(0), reflect.TypeOf(0.0), reflect.TypeOf(0i), reflect.TypeOf(""):
+		return true
+	}
+	return false
+}
+
+func (d *dumper) float(v reflect.Value, explicit bool) p.Doc {
+	f := v.Float()
+	bits := 64
+	if v.Kind() == reflect.Float32 {
+		bits = 32
+	}
+	var literal string
+	switch {
+	case math.IsNaN(f):
+		literal = "math.NaN()"
+	case math.IsInf(f, 1):
+		literal = "math.Inf(1)"
+	case math.IsInf(f, -1):
+		literal = "math.Inf(-1)"
+	default:
+		literal = strconv.FormatFloat(f, 'g', -1, bits)
+		if explicit && isDefaultType(v.Type()) && !isFloatLiteral(literal) {
+			// an integer literal is an int in interfaces.
+			return p.Text(fmt.Sprintf("%s(%s)", v.Type(), literal))
+		}
+	}
+	return d.scalar(v, literal, explicit)
+}
+
+func isFloatLiteral(literal string) bool {
+	for _, c := range literal {
+		if c == '.' || c == 'e' {
+			return true
+		}
+	}
+	return false
+}
+
+// nilValue writes nil, converted to its type if the type is not known
+// from the context.
+func (d *dumper) nilValue(v reflect.Value, explicit bool) p.Doc {
+	if explicit && v.Kind() != reflect.Interface {
+		return p.Text(fmt.Sprintf("(%s)(nil)", v.Type()))
+	}
+	return p.Text("nil")
+}
+
+func (d *dumper) cycle(v reflect.Value) p.Doc {
+	return p.Text(fmt.Sprintf("(%s)(nil) /* cycle */", v.Type()))
+}
+
+func (d *dumper) pointer(v reflect.Value, explicit bool, elide bool) p.Doc {
+	if v.IsNil() {
+		return d.nilValue(v, explicit)
+	}
+	key := visit{ptr: v.Pointer(), typ: v.Type()}
+	if d.visiting[key] {
+		return d.cycle(v)
+	}
+	d.visiting[key] = true
+	defer delete(d.visiting, key)
+	elem := v.Elem()
+	switch elem.Kind() {
+	case reflect.Struct, reflect.Array, reflect.Slice, reflect.Map:
+		if elide {
+			// &T is elided as well as T in composite literals.
+			return d.doc(elem, false, true)
+		}
+		return p.Concat([]p.Doc{p.Text("&"), d.doc(elem, false, false)})
+	}
+	// there is no literal of pointers to scalars, so take the address of
+	// an element of a slice.
+	return p.Concat([]p.Doc{
+		p.Text(fmt.Sprintf("&[]%s{", elem.Type())),
+		d.doc(elem, false, false),
+		p.Text("}[0]"),
+	})
+}
+
+func (d *dumper) list(v reflect.Value, elide bool) p.Doc {
+	items := make([]p.Doc, v.Len())
+	for i := range items {
+		items[i] = d.element(v.Index(i))
+	}
+	fill := false
+	switch v.Type().Elem().Kind() {
+	case reflect.Bool, reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64:
+		// short scalars are filled in lines.
+		fill = true
+	}
+	return d.composite(v.Type(), elide, items, fill)
+}
+
+// element converts an element of a composite literal.
+func (d *dumper) element(v reflect.Value) p.Doc {
+	return d.doc(v, false, v.Kind() != reflect.Interface)
+}
+
+type entry struct {
+	key   reflect.Value
+	value reflect.Value
+}
+
+func (d *dumper) mapValue(v reflect.Value, elide bool) p.Doc {
+	entries := []entry{}
+	iter := v.MapRange()
+	for iter.Next() {
+		entries = append(entries, entry{key: iter.Key(), value: iter.Value()})
+	}
+	keys := make([]string, len(entries))
+	for i, e := range entries {
+		keys[i] = p.Pretty(math.MaxInt32, d.element(e.key))
+	}
+	sort.Sort(byKey{entries: entries, keys: keys})
+	items := make([]p.Doc, len(entries))
+	for i, e := range entries {
+		items[i] = p.Concat([]p.Doc{d.element(e.key), p.Text(": "), d.element(e.value)})
+	}
+	return d.composite(v.Type(), elide, items, false)
+}
+
+// byKey sorts the entries of a map by their keys, in the natural order
+// of the keys if they are numbers or strings, and in the order of their
+// literals otherwise.
+type byKey struct {
+	entries []entry
+	keys    []string
+}
+
+func (b byKey) Len() int {
+	return len(b.entries)
+}
+
+func (b byKey) Swap(i, j int) {
+	b.entries[i], b.entries[j] = b.entries[j], b.entries[i]
+	b.keys[i], b.keys[j] = b.keys[j], b.keys[i]
+}
+
+func (b byKey) Less(i, j int) bool {
+	x := b.entries[i].key
+	y := b.entries[j].key
+	if x.Kind() == y.Kind() {
+		switch x.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			return x.Int() < y.Int()
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+			return x.Uint() < y.Uint()
+		case reflect.Float32, reflect.Float64:
+			return x.Float() < y.Float()
+		case reflect.String:
+			return x.String() < y.String()
+		}
+	}
+	return b.keys[i] < b.keys[j]
+}
+
+func (d *dumper) structValue(v reflect.Value, elide bool) p.Doc {
+	t := v.Type()
+	items := make([]p.Doc, t.NumField())
+	for i := range items {
+		items[i] = p.Concat([]p.Doc{
+			p.Text(t.Field(i).Name + ": "),
+			// the types of fields can't be elided.
+			d.doc(v.Field(i), false, false),
+		})
+	}
+	return d.composite(t, elide, items, false)
+}
+
+// composite writes a composite literal of the items, which has
+// a trailing comma when it is broken into lines as Go requires.
+func (d *dumper) composite(t reflect.Type, elide bool, items []p.Doc, fill bool) p.Doc {
+	left := "{"
+	if !elide {
+		left = t.String() + "{"
+	}
+	if len(items) == 0 {
+		return p.Text(left + "}")
+	}
+	sep := p.Concat([]p.Doc{p.Text(","), p.Line()})
+	var body p.Doc
+	if fill {
+		body = p.Fill(sep, items)
+	} else {
+		body = p.Intercalate(sep, items)
+	}
+	return p.TightBracketBy(
+		p.Text(left),
+		p.Text("}"),
+		p.Concat([]p.Doc{body, p.IfBreak(p.Text(","), p.Empty())}),
+		uint(2),
+	)
+}
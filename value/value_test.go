@@ -0,0 +1,227 @@
+package value
+
+import (
+	"fmt"
+	"math"
+	"testing"
+	"time"
+
+	p "github.com/tanishiking/prettier"
+)
+
+type point struct {
+	X, Y int
+}
+
+type color int
+
+type node struct {
+	Name     string
+	Children []*node
+	Parent   *node
+	Attrs    map[string]interface{}
+}
+
+func TestDocScalars(t *testing.T) {
+	tests := []struct {
+		v        interface{}
+		expected string
+	}{
+		{nil, "nil"},
+		{true, "true"},
+		{1, "1"},
+		{int64(-1), "int64(-1)"},
+		{uint(2), "uint(2)"},
+		{byte(10), "uint8(0x0a)"},
+		{1.5, "1.5"},
+		{2.0, "float64(2)"},
+		{float32(0.25), "float32(0.25)"},
+		{math.Inf(-1), "math.Inf(-1)"},
+		{complex(1, 2), "(1+2i)"},
+		{"a\"b\n", `"a\"b\n"`},
+		{color(3), "value.color(3)"},
+		{(*int)(nil), "(*int)(nil)"},
+		{[]int(nil), "([]int)(nil)"},
+		{time.Duration(0), "time.Duration(0)"},
+	}
+	for _, tt := range tests {
+		if actual := p.Pretty(80, Doc(tt.v)); actual != tt.expected {
+			t.Errorf("expected: %v, actual: %v", tt.expected, actual)
+		}
+	}
+}
+
+func TestDocComposite(t *testing.T) {
+	tests := []struct {
+		v        interface{}
+		expected string
+	}{
+		{point{X: 1, Y: 2}, "value.point{X: 1, Y: 2}"},
+		{&point{X: 1}, "&value.point{X: 1, Y: 0}"},
+		{[]point{{X: 1}}, "[]value.point{{X: 1, Y: 0}}"},
+		{[]*point{{X: 1}}, "[]*value.point{{X: 1, Y: 0}}"},
+		{[2]bool{true}, "[2]bool{true, false}"},
+		{[]interface{}{1, int8(2), "a", nil, point{}}, `[]interface {}{1, int8(2), "a", nil, value.point{X: 0, Y: 0}}`},
+		{map[string]int{"b": 2, "a": 1, "c": 3}, `map[string]int{"a": 1, "b": 2, "c": 3}`},
+		{map[int]string{10: "x", 9: "y"}, `map[int]string{9: "y", 10: "x"}`},
+		{map[point]bool{{X: 2}: true, {X: 1}: false}, `map[value.point]bool{{X: 1, Y: 0}: false, {X: 2, Y: 0}: true}`},
+		{[]string{}, "[]string{}"},
+		{struct{ A *int }{}, "struct { A *int }{A: nil}"},
+		{[]byte("hi"), "[]uint8{0x68, 0x69}"},
+	}
+	for _, tt := range tests {
+		if actual := p.Pretty(80, Doc(tt.v)); actual != tt.expected {
+			t.Errorf("expected: %v, actual: %v", tt.expected, actual)
+		}
+	}
+}
+
+func TestDocBroken(t *testing.T) {
+	n := &node{
+		Name:     "root",
+		Children: []*node{{Name: "a"}, {Name: "b", Attrs: map[string]interface{}{"size": 10}}},
+	}
+	expected := `&value.node{
+  Name: "root",
+  Children: []*value.node{
+    {Name: "a", Children: nil, Parent: nil, Attrs: nil},
+    {
+      Name: "b",
+      Children: nil,
+      Parent: nil,
+      Attrs: map[string]interface {}{"size": 10},
+    },
+  },
+  Parent: nil,
+  Attrs: nil,
+}`
+	if actual := p.Pretty(60, Doc(n)); actual != expected {
+		t.Errorf("expected: %v, actual: %v", expected, actual)
+	}
+	numbers := make([]int, 30)
+	for i := range numbers {
+		numbers[i] = i
+	}
+	expected = `[]int{
+  0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16,
+  17, 18, 19, 20, 21, 22, 23, 24, 25, 26, 27, 28, 29,
+}`
+	if actual := p.Pretty(60, Doc(numbers)); actual != expected {
+		t.Errorf("expected: %v, actual: %v", expected, actual)
+	}
+}
+
+func TestDocCycle(t *testing.T) {
+	root := &node{Name: "root"}
+	child := &node{Name: "child", Parent: root}
+	root.Children = []*node{child}
+	expected := `&value.node{
+  Name: "root",
+  Children: []*value.node{
+    {
+      Name: "child",
+      Children: nil,
+      Parent: (*value.node)(nil) /* cycle */,
+      Attrs: nil,
+    },
+  },
+  Parent: nil,
+  Attrs: nil,
+}`
+	if actual := p.Pretty(60, Doc(root)); actual != expected {
+		t.Errorf("expected: %v, actual: %v", expected, actual)
+	}
+	m := map[string]interface{}{}
+	m["self"] = m
+	expected = `map[string]interface {}{"self": (map[string]interface {})(nil) /* cycle */}`
+	if actual := p.Pretty(100, Doc(m)); actual != expected {
+		t.Errorf("expected: %v, actual: %v", expected, actual)
+	}
+	// shared values which are not cycles are written as many times.
+	shared := &point{X: 1}
+	expected = "[]*value.point{{X: 1, Y: 0}, {X: 1, Y: 0}}"
+	if actual := p.Pretty(80, Doc([]*point{shared, shared})); actual != expected {
+		t.Errorf("expected: %v, actual: %v", expected, actual)
+	}
+}
+
+type goStringer struct{}
+
+func (goStringer) GoString() string {
+	return "newGoStringer()"
+}
+
+func TestDocGoStringer(t *testing.T) {
+	expected := "[]interface {}{newGoStringer(), 1}"
+	actual := p.Pretty(100, Doc([]interface{}{goStringer{}, 1}))
+	if actual != expected {
+		t.Errorf("expected: %v, actual: %v", expected, actual)
+	}
+}
+
+type multiline struct{}
+
+func (multiline) GoString() string {
+	return "newMultiline(`a\nbc`)"
+}
+
+func TestDocGoStringerMultiline(t *testing.T) {
+	// the column after the lines is the width of the last line.
+	expected := "[]interface {}{newMultiline(`a\nbc`), 1, 2, 3}"
+	if actual := p.Pretty(40, Doc([]interface{}{multiline{}, 1, 2, 3})); actual != expected {
+		t.Errorf("expected: %q, actual: %q", expected, actual)
+	}
+	expected = "[]interface {}{\n  newMultiline(`a\nbc`),\n  1,\n  2,\n  3,\n}"
+	if actual := p.Pretty(10, Doc([]interface{}{multiline{}, 1, 2, 3})); actual != expected {
+		t.Errorf("expected: %q, actual: %q", expected, actual)
+	}
+}
+
+type version struct {
+	major, minor int
+}
+
+func (v version) PrettyDoc() p.Doc {
+	return p.Text(fmt.Sprintf("v%d.%d", v.major, v.minor))
+}
+
+func TestDocPrettier(t *testing.T) {
+	v := map[string]interface{}{
+		"version": version{major: 1, minor: 2},
+		"doc":     p.Group(p.Concat([]p.Doc{p.Text("a"), p.Line(), p.Text("b")})),
+	}
+	expected := `map[string]interface {}{"doc": a b, "version": v1.2}`
+	if actual := p.Pretty(80, Doc(v)); actual != expected {
+		t.Errorf("expected: %v, actual: %v", expected, actual)
+	}
+	// unexported fields can't be called.
+	expected = `struct { v value.version }{v: value.version{major: 1, minor: 2}}`
+	if actual := p.Pretty(80, Doc(struct{ v version }{v: version{1, 2}})); actual != expected {
+		t.Errorf("expected: %v, actual: %v", expected, actual)
+	}
+}
+
+func TestDocPointerToScalar(t *testing.T) {
+	n := 1
+	if actual := p.Pretty(80, Doc(&n)); actual != "&[]int{1}[0]" {
+		t.Errorf("expected: %v, actual: %v", "&[]int{1}[0]", actual)
+	}
+}
+
+func ExampleDoc() {
+	type user struct {
+		Name  string
+		Tags  []string
+		Admin bool
+	}
+	users := []user{
+		{Name: "alice", Tags: []string{"dev", "ops"}, Admin: true},
+		{Name: "bob"},
+	}
+	fmt.Println(p.Pretty(70, Doc(users)))
+	// Output:
+	// []value.user{
+	//   {Name: "alice", Tags: []string{"dev", "ops"}, Admin: true},
+	//   {Name: "bob", Tags: nil, Admin: false},
+	// }
+}
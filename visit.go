@@ -0,0 +1,229 @@
+package prettier
+
+import (
+	"fmt"
+)
+
+// Kind is the kind of a node of Doc.
+type Kind int
+
+// Kinds of nodes of Doc.
+const (
+	KindEmpty Kind = iota
+	KindText
+	KindLine
+	KindConcat
+	KindNest
+	KindUnion
+	KindLazy
+	KindAnnotation
+	KindCursor
+	KindGroup
+	KindFill
+	KindIfBreak
+	KindAlign
+)
+
+var kindNames = []string{
+	KindEmpty:      "Empty",
+	KindText:       "Text",
+	KindLine:       "Line",
+	KindConcat:     "Concat",
+	KindNest:       "Nest",
+	KindUnion:      "Union",
+	KindLazy:       "Lazy",
+	KindAnnotation: "Annotation",
+	KindCursor:     "Cursor",
+	KindGroup:      "Group",
+	KindFill:       "Fill",
+	KindIfBreak:    "IfBreak",
+	KindAlign:      "Align",
+}
+
+func (k Kind) String() string {
+	if k < 0 || int(k) >= len(kindNames) {
+		return fmt.Sprintf("Kind(%d)", int(k))
+	}
+	return kindNames[k]
+}
+
+// Node describes a single node of Doc, apart from its children.
+type Node struct {
+	Kind Kind
+	// Text and Length are the string and its length of a Text node.
+	Text   string
+	Length int
+	// FlattenToSpace reports whether a Line node is flattened to
+	// a space (Line), or to nothing (LineBreak).
+	FlattenToSpace bool
+	// Indent is the indentation of a Nest node.
+	Indent uint
+	// Annotation is the value attached to an Annotation node.
+	Annotation interface{}
+}
+
+// NodeOf describes the root node of the given Doc.
+func NodeOf(doc Doc) Node {
+	switch v := doc.(type) {
+	case *empty:
+		return Node{Kind: KindEmpty}
+	case *text:
+		return Node{Kind: KindText, Text: v.str, Length: v.length}
+	case *line:
+		return Node{Kind: KindLine, FlattenToSpace: v.flattenToSpace}
+	case *concat:
+		return Node{Kind: KindConcat}
+	case *nest:
+		return Node{Kind: KindNest, Indent: v.indent}
+	case *group:
+		return Node{Kind: KindGroup}
+	case *fill:
+		return Node{Kind: KindFill}
+	case *ifBreak:
+		return Node{Kind: KindIfBreak}
+	case *align:
+		return Node{Kind: KindAlign}
+	case *union:
+		return Node{Kind: KindUnion}
+	case *lazyDoc:
+		return Node{Kind: KindLazy}
+	case *annotation:
+		return Node{Kind: KindAnnotation, Annotation: v.value}
+	case *cursor:
+		return Node{Kind: KindCursor}
+	default:
+		panic(fmt.Sprintf("Error: unknown doc %v", doc))
+	}
+}
+
+// Children returns the direct children of the given Doc.
+//
+// A group has the grouped document as its only child, whose flattened
+// layout is chosen by the renderer. A fill has its separator as the first
+// child, followed by its parts. An IfBreak has the broken document first
+// and the flat document second. A union has two children, the optimistic
+// (flattened) branch first and the other branch second. A lazy document
+// has the evaluated document as its only child, so calling Children
+// evaluates it.
+func Children(doc Doc) []Doc {
+	switch v := doc.(type) {
+	case *concat:
+		return []Doc{v.a, v.b}
+	case *nest:
+		return []Doc{v.doc}
+	case *align:
+		return []Doc{v.doc}
+	case *group:
+		return []Doc{v.doc}
+	case *fill:
+		return append([]Doc{v.sep}, v.parts...)
+	case *ifBreak:
+		return []Doc{v.broken, v.flat}
+	case *union:
+		return []Doc{v.a, v.b}
+	case *lazyDoc:
+		return []Doc{v.Evaluated()}
+	case *annotation:
+		return []Doc{v.doc}
+	default:
+		return []Doc{}
+	}
+}
+
+// A Visitor's Visit method is invoked for each node encountered by Walk.
+// If the result visitor w is not nil, Walk visits each of the children
+// of doc with the visitor w, followed by a call of w.Visit(nil).
+type Visitor interface {
+	Visit(doc Doc) (w Visitor)
+}
+
+// Walk traverses a Doc in depth-first order: It starts by calling
+// v.Visit(doc); doc must not be nil. If the visitor w returned by
+// v.Visit(doc) is not nil, Walk is invoked recursively with visitor
+// w for each of the children of doc, followed by a call of w.Visit(nil).
+//
+// Lazy documents are evaluated, and subdocuments shared in the Doc are
+// visited as many times as they appear.
+func Walk(doc Doc, v Visitor) {
+	if v = v.Visit(doc); v == nil {
+		return
+	}
+	for _, child := range Children(doc) {
+		Walk(child, v)
+	}
+	v.Visit(nil)
+}
+
+type inspector func(Doc) bool
+
+func (f inspector) Visit(doc Doc) Visitor {
+	if f(doc) {
+		return f
+	}
+	return nil
+}
+
+// Inspect traverses a Doc in depth-first order: It starts by calling
+// f(doc); doc must not be nil. If f returns true, Inspect invokes f
+// recursively for each of the children of doc, followed by a call of
+// f(nil).
+func Inspect(doc Doc, f func(Doc) bool) {
+	Walk(doc, inspector(f))
+}
+
+// Transform rebuilds a Doc from the bottom up: each node is rebuilt with
+// its transformed children, and then replaced with the result of f.
+//
+// Lazy documents stay lazy; they are transformed when they are evaluated.
+func Transform(doc Doc, f func(Doc) Doc) Doc {
+	switch v := doc.(type) {
+	case *concat:
+		return f(&concat{
+			a: Transform(v.a, f),
+			b: Transform(v.b, f),
+		})
+	case *nest:
+		return f(&nest{
+			indent: v.indent,
+			doc:    Transform(v.doc, f),
+		})
+	case *align:
+		return f(&align{
+			doc: Transform(v.doc, f),
+		})
+	case *group:
+		// Group would return some children as they are, and f would be
+		// called with them twice.
+		return f(&group{doc: Transform(v.doc, f)})
+	case *ifBreak:
+		return f(&ifBreak{
+			broken: Transform(v.broken, f),
+			flat:   Transform(v.flat, f),
+		})
+	case *fill:
+		parts := make([]Doc, len(v.parts))
+		for i, part := range v.parts {
+			parts[i] = Transform(part, f)
+		}
+		return f(&fill{
+			sep:   Transform(v.sep, f),
+			parts: parts,
+		})
+	case *union:
+		return f(&union{
+			a: Transform(v.a, f),
+			b: Transform(v.b, f),
+		})
+	case *lazyDoc:
+		return f(lazy(func() Doc {
+			return Transform(v.Evaluated(), f)
+		}))
+	case *annotation:
+		return f(&annotation{
+			value: v.value,
+			doc:   Transform(v.doc, f),
+		})
+	default:
+		return f(doc)
+	}
+}
@@ -0,0 +1,133 @@
+package prettier
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestNodeOf(t *testing.T) {
+	tests := []struct {
+		doc      Doc
+		expected Node
+	}{
+		{Empty(), Node{Kind: KindEmpty}},
+		{TextWithLength("test", 2), Node{Kind: KindText, Text: "test", Length: 2}},
+		{Line(), Node{Kind: KindLine, FlattenToSpace: true}},
+		{LineBreak(), Node{Kind: KindLine, FlattenToSpace: false}},
+		{Concat([]Doc{Text("a"), Text("b")}), Node{Kind: KindConcat}},
+		{Nest(uint(2), Text("a")), Node{Kind: KindNest, Indent: uint(2)}},
+		{Group(Line()), Node{Kind: KindGroup}},
+		{Align(Line()), Node{Kind: KindAlign}},
+		{Fill(Line(), []Doc{Text("a"), Text("b")}), Node{Kind: KindFill}},
+		{IfBreak(Text(","), Empty()), Node{Kind: KindIfBreak}},
+		{&union{a: Text("a"), b: Text("b")}, Node{Kind: KindUnion}},
+		{lazy(Empty), Node{Kind: KindLazy}},
+		{Annotate("id", Text("a")), Node{Kind: KindAnnotation, Annotation: "id"}},
+		{Cursor(), Node{Kind: KindCursor}},
+	}
+	for _, tt := range tests {
+		actual := NodeOf(tt.doc)
+		if !reflect.DeepEqual(actual, tt.expected) {
+			t.Errorf("expected: %v, actual: %v", tt.expected, actual)
+		}
+	}
+}
+
+func TestKindString(t *testing.T) {
+	if KindAnnotation.String() != "Annotation" {
+		t.Errorf("expected: %v, actual: %v", "Annotation", KindAnnotation.String())
+	}
+	if Kind(100).String() != "Kind(100)" {
+		t.Errorf("expected: %v, actual: %v", "Kind(100)", Kind(100).String())
+	}
+}
+
+func TestInspect(t *testing.T) {
+	doc := Concat([]Doc{
+		Text("a"),
+		Nest(uint(2), Group(Concat([]Doc{Line(), Text("b")}))),
+		lazy(func() Doc { return Text("c") }),
+	})
+	kinds := []string{}
+	Inspect(doc, func(d Doc) bool {
+		if d == nil {
+			kinds = append(kinds, ")")
+			return false
+		}
+		kinds = append(kinds, NodeOf(d).Kind.String())
+		// don't look into groups.
+		return NodeOf(d).Kind != KindGroup
+	})
+	expected := "Concat Text ) Concat Nest Group ) Lazy Text ) ) ) )"
+	if strings.Join(kinds, " ") != expected {
+		t.Errorf("expected: %v, actual: %v", expected, strings.Join(kinds, " "))
+	}
+}
+
+type textCounter struct {
+	count int
+}
+
+func (c *textCounter) Visit(doc Doc) Visitor {
+	if doc != nil && NodeOf(doc).Kind == KindText {
+		c.count++
+	}
+	return c
+}
+
+func TestWalk(t *testing.T) {
+	doc := Intercalate(Text(","), []Doc{Text("a"), Text("b"), Text("c")})
+	counter := &textCounter{}
+	Walk(doc, counter)
+	if counter.count != 5 {
+		t.Errorf("expected: %v, actual: %v", 5, counter.count)
+	}
+}
+
+func TestTransform(t *testing.T) {
+	upper := func(d Doc) Doc {
+		if n := NodeOf(d); n.Kind == KindText {
+			return Text(strings.ToUpper(n.Text))
+		}
+		return d
+	}
+	evaluated := false
+	doc := Concat([]Doc{
+		Text("a"),
+		Nest(uint(2), Group(Concat([]Doc{Line(), Annotate("id", Text("b"))}))),
+		lazy(func() Doc {
+			evaluated = true
+			return Text("c")
+		}),
+	})
+	transformed := Transform(doc, upper)
+	if evaluated {
+		t.Errorf("Transform should not evaluate lazy documents")
+	}
+	if Pretty(80, transformed) != "A BC" {
+		t.Errorf("expected: %v, actual: %v", "A BC", Pretty(80, transformed))
+	}
+	if Pretty(0, transformed) != "A\n  BC" {
+		t.Errorf("expected: %v, actual: %v", "A\n  BC", Pretty(0, transformed))
+	}
+}
+
+func TestTransformCalls(t *testing.T) {
+	calls := 0
+	// the concatenation is replaced with a text, which is grouped.
+	f := func(d Doc) Doc {
+		calls++
+		if NodeOf(d).Kind == KindConcat {
+			return Text("x")
+		}
+		return d
+	}
+	transformed := Transform(Group(Concat([]Doc{Text("a"), Line()})), f)
+	if calls != 4 {
+		t.Errorf("expected: %v, actual: %v", 4, calls)
+	}
+	if Pretty(80, transformed) != "x" {
+		t.Errorf("expected: %v, actual: %v", "x", Pretty(80, transformed))
+	}
+}
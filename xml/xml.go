@@ -0,0 +1,561 @@
+/*
+Package xml lays out XML and HTML documents read by encoding/xml as
+documents of prettier.
+
+The attributes of a start tag are written on one line if they fit, and
+otherwise one on each line. The children of an element which has only
+elements are indented on separate lines, unless they fit on one line
+together with the tags of the element:
+
+	<config>
+	  <server host="localhost" port="8080"/>
+	</config>
+
+Whitespace is significant in mixed content, which has text: the text is
+filled in lines by breaking at the existing whitespace, and no whitespace
+is added or removed between words and tags. The contents of the elements
+which preserve whitespace are written exactly as they are read.
+*/
+package xml
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf8"
+
+	p "github.com/tanishiking/prettier"
+)
+
+// Options configures how XML documents are laid out.
+type Options struct {
+	// Indent is the indentation of the children of elements, and of the
+	// attributes broken into lines. Zero means 2.
+	Indent uint
+	// HTML reads the document as HTML: entities of HTML are decoded, the
+	// void elements such as <br> have no end tags, the elements which
+	// are not closed are closed by the end tags of their parents, and
+	// whitespace is significant around the inline elements such as <b>.
+	HTML bool
+}
+
+// Doc parses the document and converts it to a Doc.
+//
+// With Options.HTML, the contents of <script> and <style> are read as they
+// are up to their end tags, and the < which doesn't start a tag or other
+// markup is read as a text.
+func Doc(data []byte, opts Options) (p.Doc, error) {
+	var bodies []string
+	if opts.HTML {
+		data, bodies = scanHTML(data)
+	}
+	dec := xml.NewDecoder(bytes.NewReader(data))
+	if opts.HTML {
+		dec.Strict = false
+		dec.Entity = xml.HTMLEntity
+	}
+	return decode(dec, opts, bodies)
+}
+
+// Decode reads the tokens of dec until the end of the input, and converts
+// them to a Doc. The tokens are read by dec.RawToken, so the prefixes of
+// names are kept as they are written.
+func Decode(dec *xml.Decoder, opts Options) (p.Doc, error) {
+	return decode(dec, opts, nil)
+}
+
+// decode converts the tokens of dec to a Doc. The contents of the raw
+// elements of HTML are bodies in order if they are not nil.
+func decode(dec *xml.Decoder, opts Options, bodies []string) (p.Doc, error) {
+	if opts.Indent == 0 {
+		opts.Indent = 2
+	}
+	root, err := parse(dec, opts.HTML)
+	if err != nil {
+		return nil, err
+	}
+	if bodies != nil {
+		fill(root, &bodies)
+	}
+	l := &layout{opts: opts}
+	ds := []p.Doc{}
+	for _, n := range root.children {
+		if n.kind == kindText && isSpace(n.text) {
+			continue
+		}
+		ds = append(ds, l.node(n))
+	}
+	return p.Intercalate(p.LineBreak(), ds), nil
+}
+
+type kind int
+
+const (
+	kindElement kind = iota
+	kindText
+	// kindMarkup is a comment, a processing instruction or a directive,
+	// whose text is the markup as it is written.
+	kindMarkup
+)
+
+type node struct {
+	kind     kind
+	start    xml.StartElement
+	children []*node
+	text     string
+}
+
+// voidElements is the elements of HTML which have no end tags.
+var voidElements = map[string]bool{
+	"area": true, "base": true, "br": true, "col": true, "embed": true,
+	"hr": true, "img": true, "input": true, "link": true, "meta": true,
+	"param": true, "source": true, "track": true, "wbr": true,
+}
+
+// inlineElements is the elements of HTML around which whitespace is
+// significant.
+var inlineElements = map[string]bool{
+	"a": true, "abbr": true, "b": true, "bdi": true, "bdo": true,
+	"br": true, "button": true, "cite": true, "code": true, "data": true,
+	"dfn": true, "em": true, "i": true, "img": true, "input": true,
+	"kbd": true, "label": true, "mark": true, "q": true, "s": true,
+	"samp": true, "select": true, "small": true, "span": true,
+	"strong": true, "sub": true, "sup": true, "time": true, "u": true,
+	"var": true,
+}
+
+// preservedElements is the elements of HTML whose contents are preserved.
+var preservedElements = map[string]bool{
+	"pre": true, "textarea": true, "script": true, "style": true,
+}
+
+// rawElements is the elements of HTML whose texts are not escaped.
+var rawElements = map[string]bool{
+	"script": true, "style": true,
+}
+
+// blockElements is the elements of HTML which close paragraphs.
+var blockElements = map[string]bool{
+	"address": true, "article": true, "aside": true, "blockquote": true,
+	"div": true, "dl": true, "fieldset": true, "footer": true, "form": true,
+	"h1": true, "h2": true, "h3": true, "h4": true, "h5": true, "h6": true,
+	"header": true, "hr": true, "main": true, "nav": true, "ol": true,
+	"p": true, "pre": true, "section": true, "table": true, "ul": true,
+}
+
+// closes reports whether the start tag of next closes the element of
+// HTML which is not closed yet, such as <li> followed by <li>.
+func closes(open string, next string) bool {
+	switch open {
+	case "p":
+		return blockElements[next]
+	case "li", "option", "tr":
+		return next == open
+	case "dt", "dd":
+		return next == "dt" || next == "dd"
+	case "td", "th":
+		return next == "td" || next == "th" || next == "tr"
+	}
+	return false
+}
+
+func parse(dec *xml.Decoder, html bool) (*node, error) {
+	root := &node{kind: kindElement}
+	stack := []*node{root}
+	for {
+		tok, err := dec.RawToken()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+		top := stack[len(stack)-1]
+		switch t := tok.(type) {
+		case xml.StartElement:
+			if html && len(stack) > 1 && closes(strings.ToLower(top.start.Name.Local), strings.ToLower(t.Name.Local)) {
+				stack = stack[:len(stack)-1]
+				top = stack[len(stack)-1]
+			}
+			n := &node{kind: kindElement, start: t.Copy()}
+			top.children = append(top.children, n)
+			if !html || !voidElements[strings.ToLower(t.Name.Local)] {
+				stack = append(stack, n)
+			}
+		case xml.EndElement:
+			i := len(stack) - 1
+			for i > 0 && !sameName(stack[i].start.Name, t.Name, html) {
+				i--
+			}
+			switch {
+			case i > 0 && (html || i == len(stack)-1):
+				// in HTML, the elements which are not closed yet are closed
+				// by the end tag of their parent.
+				stack = stack[:i]
+			case html:
+				// a stray end tag such as </br> is ignored.
+			default:
+				return nil, fmt.Errorf("prettier/xml: unexpected </%s>", name(t.Name))
+			}
+		case xml.CharData:
+			top.children = append(top.children, &node{kind: kindText, text: string(t)})
+		case xml.Comment:
+			top.children = append(top.children, &node{kind: kindMarkup, text: "<!--" + string(t) + "-->"})
+		case xml.ProcInst:
+			text := "<?" + t.Target
+			if len(t.Inst) > 0 {
+				text += " " + string(t.Inst)
+			}
+			top.children = append(top.children, &node{kind: kindMarkup, text: text + "?>"})
+		case xml.Directive:
+			top.children = append(top.children, &node{kind: kindMarkup, text: "<!" + string(t) + ">"})
+		}
+	}
+	if len(stack) > 1 && !html {
+		return nil, fmt.Errorf("prettier/xml: unclosed <%s>", name(stack[len(stack)-1].start.Name))
+	}
+	return root, nil
+}
+
+// scanHTML removes the contents of the raw elements of HTML from data
+// except for their newlines, and returns them in order. The < which
+// doesn't start markup and the < in the values of attributes are escaped,
+// so that the rest of data can be read by encoding/xml.
+func scanHTML(data []byte) ([]byte, []string) {
+	var b bytes.Buffer
+	bodies := []string{}
+	for i := 0; i < len(data); {
+		c := data[i]
+		rest := data[i:]
+		switch {
+		case c != '<':
+			b.WriteByte(c)
+			i++
+		case bytes.HasPrefix(rest, []byte("<!--")):
+			n := bytes.Index(rest[4:], []byte("-->"))
+			if n < 0 {
+				n = len(rest) - 7
+			}
+			b.Write(rest[:n+7])
+			i += n + 7
+		case len(rest) > 1 && (rest[1] == '!' || rest[1] == '?' || rest[1] == '/' || isLetter(rest[1])):
+			n := tagEnd(rest)
+			if n < 0 {
+				b.WriteString("&lt;")
+				i++
+				continue
+			}
+			// the < in the values of the attributes.
+			b.WriteByte('<')
+			b.Write(bytes.Replace(rest[1:n], []byte("<"), []byte("&lt;"), -1))
+			i += n
+			tag := tagName(rest[1:n])
+			if rest[1] == '/' || !rawElements[tag] {
+				continue
+			}
+			body := []byte{}
+			if !bytes.HasSuffix(rest[:n], []byte("/>")) {
+				body = rawBody(data[i:], tag)
+			}
+			bodies = append(bodies, string(body))
+			// the lines of the contents are kept for the line numbers of
+			// errors.
+			b.Write(bytes.Repeat([]byte("\n"), bytes.Count(body, []byte("\n"))))
+			i += len(body)
+		default:
+			b.WriteString("&lt;")
+			i++
+		}
+	}
+	return b.Bytes(), bodies
+}
+
+// tagEnd is the length of the tag at the start of s, or -1 if another <
+// or the end of s is found before the > closing the tag. The quoted
+// values of the attributes may have < and >.
+func tagEnd(s []byte) int {
+	var quote byte
+	for i := 1; i < len(s); i++ {
+		switch c := s[i]; {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '>':
+			return i + 1
+		case c == '<':
+			return -1
+		}
+	}
+	return -1
+}
+
+// tagName is the lower-case name of the start tag whose < is removed.
+func tagName(s []byte) string {
+	n := 0
+	for n < len(s) && (isLetter(s[n]) || s[n] >= '0' && s[n] <= '9' || s[n] == '-' || s[n] == ':') {
+		n++
+	}
+	return strings.ToLower(string(s[:n]))
+}
+
+// rawBody is the contents of the raw element up to its end tag, or up to
+// the end of s if it isn't closed.
+func rawBody(s []byte, tag string) []byte {
+	lower := bytes.ToLower(s)
+	end := []byte("</" + tag)
+	for i := 0; ; {
+		n := bytes.Index(lower[i:], end)
+		if n < 0 {
+			return s
+		}
+		i += n + len(end)
+		if i == len(s) || s[i] == '>' || s[i] == '/' || isSpaceRune(rune(s[i])) {
+			return s[:i-len(end)]
+		}
+	}
+}
+
+func isLetter(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+// fill sets the contents of the raw elements of HTML to the bodies in
+// order.
+func fill(n *node, bodies *[]string) {
+	for _, c := range n.children {
+		if c.kind != kindElement {
+			continue
+		}
+		if rawElements[strings.ToLower(c.start.Name.Local)] && len(*bodies) > 0 {
+			c.children = nil
+			if body := (*bodies)[0]; body != "" {
+				c.children = []*node{{kind: kindText, text: body}}
+			}
+			*bodies = (*bodies)[1:]
+			continue
+		}
+		fill(c, bodies)
+	}
+}
+
+type layout struct {
+	opts Options
+}
+
+func (l *layout) node(n *node) p.Doc {
+	switch n.kind {
+	case kindText:
+		return p.Text(escapeText(n.text))
+	case kindMarkup:
+		return raw(n.text)
+	}
+	tag := name(n.start.Name)
+	if l.preserves(n) {
+		var b strings.Builder
+		for _, c := range n.children {
+			l.raw(&b, c, l.opts.HTML && rawElements[strings.ToLower(n.start.Name.Local)])
+		}
+		return p.Concat([]p.Doc{l.open(n, ">"), raw(b.String()), p.Text("</" + tag + ">")})
+	}
+	if l.opts.HTML && voidElements[strings.ToLower(n.start.Name.Local)] {
+		return l.open(n, ">")
+	}
+	if l.isMixed(n) {
+		return l.mixed(n)
+	}
+	ds := []p.Doc{}
+	for _, c := range n.children {
+		if c.kind != kindText {
+			ds = append(ds, l.node(c))
+		}
+	}
+	if len(ds) == 0 {
+		if l.opts.HTML {
+			return p.Concat([]p.Doc{l.open(n, ">"), p.Text("</" + tag + ">")})
+		}
+		return l.open(n, "/>")
+	}
+	return p.TightBracketBy(l.open(n, ">"), p.Text("</"+tag+">"), p.Intercalate(p.LineBreak(), ds), l.opts.Indent)
+}
+
+// open lays out the start tag, whose attributes are written on separate
+// lines if they don't fit.
+func (l *layout) open(n *node, end string) p.Doc {
+	ds := []p.Doc{p.Text("<" + name(n.start.Name))}
+	attrs := []p.Doc{}
+	for _, attr := range n.start.Attr {
+		attrs = append(attrs, p.Line(), p.Text(name(attr.Name)+`="`+escapeAttr(attr.Value)+`"`))
+	}
+	ds = append(ds, p.Nest(l.opts.Indent, p.Concat(attrs)), p.Text(end))
+	return p.Group(p.Concat(ds))
+}
+
+// mixed lays out the element which has text. The words and the tags which
+// are not separated by whitespace are never broken, and the whitespace is
+// written as a space or a newline.
+func (l *layout) mixed(n *node) p.Doc {
+	parts := []p.Doc{}
+	part := []p.Doc{}
+	leading, trailing := false, false
+	space := func() {
+		if len(part) > 0 {
+			parts = append(parts, p.Concat(part))
+			part = []p.Doc{}
+		}
+		if len(parts) == 0 {
+			leading = true
+		}
+		trailing = true
+	}
+	for _, c := range n.children {
+		if c.kind != kindText {
+			part = append(part, l.node(c))
+			trailing = false
+			continue
+		}
+		words := strings.FieldsFunc(c.text, isSpaceRune)
+		if len(words) == 0 {
+			space()
+			continue
+		}
+		if isSpaceRune(rune(c.text[0])) {
+			space()
+		}
+		for i, word := range words {
+			if i > 0 {
+				space()
+			}
+			part = append(part, p.Text(escapeText(word)))
+			trailing = false
+		}
+		if isSpaceRune(rune(c.text[len(c.text)-1])) {
+			space()
+		}
+	}
+	if len(part) > 0 {
+		parts = append(parts, p.Concat(part))
+	}
+	before, after := p.Empty(), p.Empty()
+	if leading {
+		before = p.Line()
+	}
+	if trailing && len(parts) > 0 {
+		after = p.Line()
+	}
+	return p.Group(p.Concat([]p.Doc{
+		l.open(n, ">"),
+		p.Nest(l.opts.Indent, p.Concat([]p.Doc{before, p.Fill(p.Line(), parts)})),
+		after,
+		p.Text("</" + name(n.start.Name) + ">"),
+	}))
+}
+
+// isMixed reports whether whitespace is significant in the element.
+func (l *layout) isMixed(n *node) bool {
+	for _, c := range n.children {
+		if c.kind == kindText && !isSpace(c.text) {
+			return true
+		}
+		if l.opts.HTML && c.kind == kindElement && inlineElements[strings.ToLower(c.start.Name.Local)] {
+			return true
+		}
+	}
+	return false
+}
+
+// preserves reports whether the contents of the element are written
+// exactly as they are read.
+func (l *layout) preserves(n *node) bool {
+	for _, attr := range n.start.Attr {
+		if attr.Name.Space == "xml" && attr.Name.Local == "space" {
+			return attr.Value == "preserve"
+		}
+	}
+	return l.opts.HTML && preservedElements[strings.ToLower(n.start.Name.Local)]
+}
+
+// raw writes the node as it is read. Texts are escaped unless unescaped
+// is set.
+func (l *layout) raw(b *strings.Builder, n *node, unescaped bool) {
+	switch n.kind {
+	case kindText:
+		if unescaped {
+			b.WriteString(n.text)
+		} else {
+			b.WriteString(escapeText(n.text))
+		}
+		return
+	case kindMarkup:
+		b.WriteString(n.text)
+		return
+	}
+	b.WriteString("<" + name(n.start.Name))
+	for _, attr := range n.start.Attr {
+		b.WriteString(" " + name(attr.Name) + `="` + escapeAttr(attr.Value) + `"`)
+	}
+	if l.opts.HTML && voidElements[strings.ToLower(n.start.Name.Local)] {
+		b.WriteString(">")
+		return
+	}
+	if len(n.children) == 0 && !l.opts.HTML {
+		b.WriteString("/>")
+		return
+	}
+	b.WriteString(">")
+	for _, c := range n.children {
+		l.raw(b, c, unescaped)
+	}
+	b.WriteString("</" + name(n.start.Name) + ">")
+}
+
+// raw is the text which may have newlines. Only the first line is counted
+// in the width, since the rest of the lines are not indented.
+func raw(s string) p.Doc {
+	if i := strings.IndexByte(s, '\n'); i >= 0 {
+		return p.TextWithLength(s, utf8.RuneCountInString(s[:i]))
+	}
+	return p.Text(s)
+}
+
+func sameName(a xml.Name, b xml.Name, html bool) bool {
+	if html {
+		return strings.EqualFold(a.Space, b.Space) && strings.EqualFold(a.Local, b.Local)
+	}
+	return a == b
+}
+
+func name(n xml.Name) string {
+	if n.Space == "" {
+		return n.Local
+	}
+	return n.Space + ":" + n.Local
+}
+
+var textEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+
+var attrEscaper = strings.NewReplacer(
+	"&", "&amp;", "<", "&lt;", `"`, "&quot;",
+	"\n", "&#xA;", "\r", "&#xD;", "\t", "&#x9;",
+)
+
+func escapeText(s string) string {
+	return textEscaper.Replace(s)
+}
+
+func escapeAttr(s string) string {
+	return attrEscaper.Replace(s)
+}
+
+// isSpaceRune reports whether the rune is whitespace of XML. Note that
+// non-breaking spaces are not whitespace.
+func isSpaceRune(r rune) bool {
+	return r == ' ' || r == '\t' || r == '\n' || r == '\r'
+}
+
+func isSpace(s string) bool {
+	return strings.TrimFunc(s, isSpaceRune) == ""
+}
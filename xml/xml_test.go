@@ -0,0 +1,220 @@
+package xml
+
+import (
+	"encoding/xml"
+	"fmt"
+	"math/rand"
+	"strings"
+	"testing"
+
+	p "github.com/tanishiking/prettier"
+)
+
+func pretty(t *testing.T, src string, width int, opts Options) string {
+	t.Helper()
+	doc, err := Doc([]byte(src), opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return p.Pretty(width, doc)
+}
+
+const config = `<?xml version="1.0"?>
+<!-- settings -->
+<config xmlns:x="urn:x"><server host="localhost" port="8080" x:timeout="30"/>
+<x:users>
+  <user name="a &amp; &quot;b&quot;"/>   <user name="c"></user>
+</x:users><empty>  </empty></config>`
+
+func TestDoc(t *testing.T) {
+	tests := []struct {
+		width    int
+		expected string
+	}{
+		{60, `<?xml version="1.0"?>
+<!-- settings -->
+<config xmlns:x="urn:x">
+  <server host="localhost" port="8080" x:timeout="30"/>
+  <x:users>
+    <user name="a &amp; &quot;b&quot;"/>
+    <user name="c"/>
+  </x:users>
+  <empty/>
+</config>`},
+		{30, `<?xml version="1.0"?>
+<!-- settings -->
+<config xmlns:x="urn:x">
+  <server
+    host="localhost"
+    port="8080"
+    x:timeout="30"/>
+  <x:users>
+    <user
+      name="a &amp; &quot;b&quot;"/>
+    <user name="c"/>
+  </x:users>
+  <empty/>
+</config>`},
+	}
+	for _, tt := range tests {
+		if actual := pretty(t, config, tt.width, Options{}); actual != tt.expected {
+			t.Errorf("expected: %v, actual: %v", tt.expected, actual)
+		}
+	}
+}
+
+func TestDocMixed(t *testing.T) {
+	src := `<doc><p>Some <em>emphasized</em>text, and
+	a <code>tag</code>.</p><p> spaced </p></doc>`
+	expected := `<doc>
+  <p>Some
+    <em>emphasized</em>text, and
+    a <code>tag</code>.</p>
+  <p> spaced </p>
+</doc>`
+	if actual := pretty(t, src, 32, Options{}); actual != expected {
+		t.Errorf("expected: %v, actual: %v", expected, actual)
+	}
+	expected = `<doc>
+  <p>Some
+    <em>emphasized</em>text,
+    and a
+    <code>tag</code>.</p>
+  <p>
+    spaced
+  </p>
+</doc>`
+	if actual := pretty(t, src, 10, Options{}); actual != expected {
+		t.Errorf("expected: %v, actual: %v", expected, actual)
+	}
+}
+
+func TestDocPreserve(t *testing.T) {
+	src := "<doc><code xml:space=\"preserve\">  a &lt;\n    <b>b</b>  </code></doc>"
+	expected := "<doc>\n  <code xml:space=\"preserve\">  a &lt;\n    <b>b</b>  </code>\n</doc>"
+	if actual := pretty(t, src, 50, Options{}); actual != expected {
+		t.Errorf("expected: %v, actual: %v", expected, actual)
+	}
+}
+
+func TestDocHTML(t *testing.T) {
+	src := `<!DOCTYPE html><html><body><p class="intro">Hello <b>world</b>,<br> this&nbsp;is
+<a href="/x">a link</a>.<p>second<pre>  a
+  b &lt; c</pre><script>if (a && b) {}</script><ul><li>one<li>two</ul><div></div></body></html>`
+	expected := `<!DOCTYPE html>
+<html>
+  <body>
+    <p class="intro">Hello
+      <b>world</b>,<br> this` + "\u00a0" + `is
+      <a href="/x">a link</a>.</p>
+    <p>second</p>
+    <pre>  a
+  b &lt; c</pre>
+    <script>if (a && b) {}</script>
+    <ul><li>one</li><li>two</li></ul>
+    <div></div>
+  </body>
+</html>`
+	if actual := pretty(t, src, 40, Options{HTML: true}); actual != expected {
+		t.Errorf("expected: %v, actual: %v", expected, actual)
+	}
+}
+
+func TestDocHTMLRaw(t *testing.T) {
+	src := `<div><script>if (a < b && c) { s = "</b>"; }</script><STYLE>p > a { }</style >
+<p title="a < b">1 < 2 and 3<4</p><script src="x.js"></script></div>`
+	expected := `<div>
+  <script>if (a < b && c) { s = "</b>"; }</script>
+  <STYLE>p > a { }</STYLE>
+  <p title="a &lt; b">1 &lt; 2 and 3&lt;4</p>
+  <script src="x.js"></script>
+</div>`
+	if actual := pretty(t, src, 80, Options{HTML: true}); actual != expected {
+		t.Errorf("expected: %v, actual: %v", expected, actual)
+	}
+}
+
+// TestDocFill checks that long texts are filled within the width, and the
+// words and the whitespace between them are kept.
+func TestDocFill(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	var b strings.Builder
+	b.WriteString("<doc><p>")
+	for i := 0; i < 5000; i++ {
+		switch r.Intn(10) {
+		case 0:
+			b.WriteString("<b>bold</b>")
+		case 1:
+			b.WriteString("\n\t")
+		case 2:
+			b.WriteString(",")
+		default:
+			b.WriteString(strings.Repeat("w", 1+r.Intn(8)) + " ")
+		}
+	}
+	b.WriteString("</p></doc>")
+	src := b.String()
+	actual := pretty(t, src, 40, Options{})
+	for _, line := range strings.Split(actual, "\n") {
+		if len(line) > 40 && strings.Contains(strings.TrimSpace(line), " ") {
+			t.Fatalf("too long line: %q", line)
+		}
+	}
+	if normalize(t, actual) != normalize(t, src) {
+		t.Errorf("the contents are changed")
+	}
+}
+
+// normalize returns the tokens of the document, ignoring the whitespace
+// between elements and replacing the whitespace in texts with single
+// spaces.
+func normalize(t *testing.T, src string) string {
+	t.Helper()
+	dec := xml.NewDecoder(strings.NewReader(src))
+	var b strings.Builder
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			break
+		}
+		if c, ok := tok.(xml.CharData); ok {
+			if text := strings.Join(strings.Fields(string(c)), " "); text != "" {
+				// whether the text starts or ends with whitespace.
+				fmt.Fprintf(&b, "%v%s%v|", isSpaceRune(rune(c[0])), text, isSpaceRune(rune(c[len(c)-1])))
+			}
+		} else {
+			fmt.Fprintf(&b, "%v|", tok)
+		}
+	}
+	return b.String()
+}
+
+func TestDocError(t *testing.T) {
+	tests := []struct {
+		src      string
+		expected string
+	}{
+		{"<a><b></a>", "prettier/xml: unexpected </a>"},
+		{"<a><b/>", "prettier/xml: unclosed <a>"},
+		{"<a x=1/>", "XML syntax error on line 1: unquoted or missing attribute value in element"},
+	}
+	for _, tt := range tests {
+		_, err := Doc([]byte(tt.src), Options{})
+		if err == nil || err.Error() != tt.expected {
+			t.Errorf("expected: %v, actual: %v", tt.expected, err)
+		}
+	}
+}
+
+func ExampleDoc() {
+	doc, err := Doc([]byte(`<list><item id="1">first</item><item id="2">second <b>item</b></item></list>`), Options{})
+	if err != nil {
+		panic(err)
+	}
+	fmt.Println(p.Pretty(40, doc))
+	// Output:
+	// <list>
+	//   <item id="1">first</item>
+	//   <item id="2">second <b>item</b></item>
+	// </list>
+}
@@ -0,0 +1,139 @@
+package yaml
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strconv"
+)
+
+// Kind is the kind of a Node.
+type Kind int
+
+// Kinds of Node.
+const (
+	KindScalar Kind = iota
+	KindSequence
+	KindMapping
+)
+
+// Tags of scalars which are resolved from their values.
+const (
+	TagString = "!!str"
+	TagInt    = "!!int"
+	TagFloat  = "!!float"
+	TagBool   = "!!bool"
+	TagNull   = "!!null"
+)
+
+// Node is a node of a YAML document, in the same shape as the nodes of
+// the popular YAML libraries.
+type Node struct {
+	Kind Kind
+	// Tag is the tag of a scalar. A string scalar (TagString) which would
+	// be read as another type is quoted. Empty means the tag is resolved
+	// from the value.
+	Tag string
+	// Value is the value of a scalar.
+	Value string
+	// Content is the items of a sequence, or the keys and the values of
+	// a mapping in turn.
+	Content []*Node
+	// HeadComment is the comment lines before the node, LineComment is
+	// the comment at the end of the line of the node, and FootComment is
+	// the comment lines after the node. `# ` is added to the lines which
+	// don't start with `#`.
+	HeadComment string
+	LineComment string
+	FootComment string
+}
+
+// NodeOf converts a Go value made of maps, slices and scalars, such as
+// values decoded from JSON, to a Node. The keys of maps are sorted.
+func NodeOf(v interface{}) *Node {
+	if v == nil {
+		return &Node{Kind: KindScalar, Tag: TagNull, Value: "null"}
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Bool:
+		return &Node{Kind: KindScalar, Tag: TagBool, Value: strconv.FormatBool(rv.Bool())}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return &Node{Kind: KindScalar, Tag: TagInt, Value: strconv.FormatInt(rv.Int(), 10)}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return &Node{Kind: KindScalar, Tag: TagInt, Value: strconv.FormatUint(rv.Uint(), 10)}
+	case reflect.Float32, reflect.Float64:
+		return &Node{Kind: KindScalar, Tag: TagFloat, Value: formatFloat(rv.Float())}
+	case reflect.String:
+		return &Node{Kind: KindScalar, Tag: TagString, Value: rv.String()}
+	case reflect.Slice, reflect.Array:
+		if rv.Kind() == reflect.Slice && rv.IsNil() {
+			return &Node{Kind: KindScalar, Tag: TagNull, Value: "null"}
+		}
+		n := &Node{Kind: KindSequence, Content: []*Node{}}
+		for i := 0; i < rv.Len(); i++ {
+			n.Content = append(n.Content, NodeOf(rv.Index(i).Interface()))
+		}
+		return n
+	case reflect.Map:
+		if rv.IsNil() {
+			return &Node{Kind: KindScalar, Tag: TagNull, Value: "null"}
+		}
+		keys := rv.MapKeys()
+		names := make([]string, len(keys))
+		for i, key := range keys {
+			names[i] = fmt.Sprint(key.Interface())
+		}
+		sort.Sort(byName{keys: keys, names: names})
+		n := &Node{Kind: KindMapping, Content: []*Node{}}
+		for _, key := range keys {
+			n.Content = append(n.Content, NodeOf(key.Interface()), NodeOf(rv.MapIndex(key).Interface()))
+		}
+		return n
+	case reflect.Ptr, reflect.Interface:
+		if rv.IsNil() {
+			return &Node{Kind: KindScalar, Tag: TagNull, Value: "null"}
+		}
+		return NodeOf(rv.Elem().Interface())
+	default:
+		return &Node{Kind: KindScalar, Tag: TagString, Value: fmt.Sprint(v)}
+	}
+}
+
+func formatFloat(f float64) string {
+	switch {
+	case math.IsNaN(f):
+		return ".nan"
+	case math.IsInf(f, 1):
+		return ".inf"
+	case math.IsInf(f, -1):
+		return "-.inf"
+	}
+	s := strconv.FormatFloat(f, 'g', -1, 64)
+	for _, c := range s {
+		if c == '.' || c == 'e' {
+			return s
+		}
+	}
+	// keep it a float.
+	return s + ".0"
+}
+
+type byName struct {
+	keys  []reflect.Value
+	names []string
+}
+
+func (b byName) Len() int {
+	return len(b.keys)
+}
+
+func (b byName) Swap(i, j int) {
+	b.keys[i], b.keys[j] = b.keys[j], b.keys[i]
+	b.names[i], b.names[j] = b.names[j], b.names[i]
+}
+
+func (b byName) Less(i, j int) bool {
+	return b.names[i] < b.names[j]
+}
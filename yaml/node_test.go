@@ -0,0 +1,49 @@
+package yaml
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestNodeOf(t *testing.T) {
+	type name string
+	actual := NodeOf(map[interface{}]interface{}{
+		"b":  []interface{}{1, uint8(2), 1.5, 2.0, math.Inf(-1), true, nil},
+		"a":  name("x"),
+		1:    map[string]int(nil),
+		"c":  &[]string{"y"},
+		"d":  [0]int{},
+		"e":  struct{}{},
+		"10": []string(nil),
+	})
+	expected := &Node{Kind: KindMapping, Content: []*Node{
+		{Kind: KindScalar, Tag: TagInt, Value: "1"},
+		{Kind: KindScalar, Tag: TagNull, Value: "null"},
+		{Kind: KindScalar, Tag: TagString, Value: "10"},
+		{Kind: KindScalar, Tag: TagNull, Value: "null"},
+		{Kind: KindScalar, Tag: TagString, Value: "a"},
+		{Kind: KindScalar, Tag: TagString, Value: "x"},
+		{Kind: KindScalar, Tag: TagString, Value: "b"},
+		{Kind: KindSequence, Content: []*Node{
+			{Kind: KindScalar, Tag: TagInt, Value: "1"},
+			{Kind: KindScalar, Tag: TagInt, Value: "2"},
+			{Kind: KindScalar, Tag: TagFloat, Value: "1.5"},
+			{Kind: KindScalar, Tag: TagFloat, Value: "2.0"},
+			{Kind: KindScalar, Tag: TagFloat, Value: "-.inf"},
+			{Kind: KindScalar, Tag: TagBool, Value: "true"},
+			{Kind: KindScalar, Tag: TagNull, Value: "null"},
+		}},
+		{Kind: KindScalar, Tag: TagString, Value: "c"},
+		{Kind: KindSequence, Content: []*Node{
+			{Kind: KindScalar, Tag: TagString, Value: "y"},
+		}},
+		{Kind: KindScalar, Tag: TagString, Value: "d"},
+		{Kind: KindSequence, Content: []*Node{}},
+		{Kind: KindScalar, Tag: TagString, Value: "e"},
+		{Kind: KindScalar, Tag: TagString, Value: "{}"},
+	}}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("expected: %v, actual: %v", Format(expected, 80, Options{}), Format(actual, 80, Options{}))
+	}
+}
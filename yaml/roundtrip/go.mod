@@ -0,0 +1,10 @@
+module github.com/tanishiking/prettier/yaml/roundtrip
+
+go 1.16
+
+require (
+	github.com/tanishiking/prettier v0.0.0
+	gopkg.in/yaml.v3 v3.0.1
+)
+
+replace github.com/tanishiking/prettier => ../..
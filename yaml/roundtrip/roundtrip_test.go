@@ -0,0 +1,43 @@
+// Package roundtrip tests that the YAML written by the yaml package is
+// read back as the same values by gopkg.in/yaml.v3. It is a module of its
+// own, so that the yaml package doesn't depend on gopkg.in/yaml.v3.
+package roundtrip
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/tanishiking/prettier/yaml"
+	yamlv3 "gopkg.in/yaml.v3"
+)
+
+func scalar(value string) *yaml.Node {
+	return &yaml.Node{Kind: yaml.KindScalar, Tag: yaml.TagString, Value: value}
+}
+
+func TestFormatRoundTrip(t *testing.T) {
+	values := []string{
+		"web", "", "true", "8080", "2001-12-14", "190:20:30", "a: b", " padded ",
+		"bell\a", "\ufeffbom", "line\n", "l1\nl2", "l1\nl2\n", "x\n\n", "a\n\nb\n",
+		"  indented\n", "trailing \n", "next\u0085line", "\u009f",
+	}
+	for _, v := range values {
+		docs := []struct {
+			node     *yaml.Node
+			expected interface{}
+		}{
+			{scalar(v), v},
+			{&yaml.Node{Kind: yaml.KindSequence, Content: []*yaml.Node{scalar("a"), scalar(v)}}, []interface{}{"a", v}},
+			{&yaml.Node{Kind: yaml.KindMapping, Content: []*yaml.Node{scalar("a"), scalar(v)}}, map[string]interface{}{"a": v}},
+		}
+		for _, doc := range docs {
+			out := yaml.Format(doc.node, 80, yaml.Options{})
+			var actual interface{}
+			if err := yamlv3.Unmarshal([]byte(out), &actual); err != nil {
+				t.Errorf("%q: %v", out, err)
+			} else if !reflect.DeepEqual(actual, doc.expected) {
+				t.Errorf("%q: expected: %#v, actual: %#v", out, doc.expected, actual)
+			}
+		}
+	}
+}
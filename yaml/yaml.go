@@ -0,0 +1,384 @@
+/*
+Package yaml lays out YAML node trees as documents of prettier.
+
+A sequence or a mapping is written in flow style if it fits on the line:
+
+	ports: [80, 443]
+	labels: {app: web, tier: frontend}
+
+Otherwise it is written in block style, and the nested collections are
+laid out in the same way:
+
+	containers:
+	  - name: web
+	    ports: [80, 443]
+
+Strings are quoted only if they would not be read back as the same
+strings, and multi-line strings are written as literal block scalars.
+The collections enclosing comments or multi-line strings are always
+written in block style.
+*/
+package yaml
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+
+	p "github.com/tanishiking/prettier"
+)
+
+// Options configures how YAML nodes are laid out.
+type Options struct {
+	// Indent is the indentation of the block collections and the block
+	// scalars nested in mappings. Zero means 2. The contents of the items
+	// of block sequences are always aligned after "- ".
+	Indent uint
+}
+
+// Format renders the YAML node within the width when possible.
+// The lines of the output have no trailing spaces, and the output ends
+// with a newline, which the block scalar at the end of the output needs
+// to keep its trailing newlines.
+func Format(n *Node, width int, opts Options) string {
+	lines := strings.Split(p.Pretty(width, Doc(n, opts)), "\n")
+	for i, line := range lines {
+		// empty lines of block scalars are indented.
+		if strings.TrimLeft(line, " ") == "" {
+			lines[i] = ""
+		}
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
+
+// Doc lays out the YAML node as a document.
+func Doc(n *Node, opts Options) p.Doc {
+	if opts.Indent == 0 {
+		opts.Indent = 2
+	}
+	e := &emitter{
+		opts:  opts,
+		hard:  map[*Node]bool{},
+		flows: map[*Node]p.Doc{},
+	}
+	e.markHard(n)
+	return e.comments(n.HeadComment, e.item(n, opts.Indent), n.FootComment)
+}
+
+type emitter struct {
+	opts Options
+	// hard is the nodes which must be written in block style.
+	hard map[*Node]bool
+	// flows is the nodes laid out in flow style.
+	flows map[*Node]p.Doc
+}
+
+// markHard marks the nodes which have comments or multi-line strings,
+// and the nodes enclosing them.
+func (e *emitter) markHard(n *Node) bool {
+	hard := n.HeadComment != "" || n.LineComment != "" || n.FootComment != "" || isMultiline(n)
+	for _, c := range n.Content {
+		if e.markHard(c) {
+			hard = true
+		}
+	}
+	e.hard[n] = hard
+	return hard
+}
+
+// item lays out the node after "- " or at the top level. The first line
+// of a block collection starts on the current line, and the lines of
+// a block scalar are indented by indent.
+func (e *emitter) item(n *Node, indent uint) p.Doc {
+	if n.Kind == KindScalar || len(n.Content) == 0 {
+		return e.scalar(n, indent)
+	}
+	block := e.block(n)
+	if n.LineComment != "" {
+		block = p.Concat([]p.Doc{comment(n.LineComment), p.LineBreak(), block})
+	}
+	return e.choose(n, block, e.flow(n))
+}
+
+// scalar lays out the scalar or the empty collection followed by its line
+// comment. The lines of a block scalar are indented by indent.
+func (e *emitter) scalar(n *Node, indent uint) p.Doc {
+	var doc p.Doc
+	if isMultiline(n) {
+		indicator, lines := literal(n.Value)
+		ds := []p.Doc{}
+		for _, line := range lines {
+			ds = append(ds, p.LineBreak(), p.Text(line))
+		}
+		doc = p.Concat([]p.Doc{
+			p.Text(indicator),
+			lineComment(n.LineComment),
+			p.Nest(indent, p.Concat(ds)),
+		})
+	} else {
+		doc = p.Concat([]p.Doc{e.flow(n), lineComment(n.LineComment)})
+	}
+	return doc
+}
+
+// choose lays out the collection in flow style if it fits, and in block
+// style otherwise.
+func (e *emitter) choose(n *Node, block p.Doc, flow p.Doc) p.Doc {
+	if e.hard[n] {
+		return block
+	}
+	return p.Group(p.IfBreak(block, flow))
+}
+
+// block lays out the non-empty collection in block style.
+func (e *emitter) block(n *Node) p.Doc {
+	ds := []p.Doc{}
+	if n.Kind == KindSequence {
+		for _, item := range n.Content {
+			doc := p.Concat([]p.Doc{p.Text("- "), p.Nest(2, e.item(item, 0))})
+			ds = append(ds, e.comments(item.HeadComment, doc, item.FootComment))
+		}
+		return p.Intercalate(p.LineBreak(), ds)
+	}
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		ds = append(ds, e.entry(n.Content[i], n.Content[i+1]))
+	}
+	return p.Intercalate(p.LineBreak(), ds)
+}
+
+// entry lays out the key and the value of a block mapping.
+func (e *emitter) entry(key *Node, value *Node) p.Doc {
+	head := key.HeadComment
+	foot := joinComments(key.FootComment, value.FootComment)
+	line := joinComments(key.LineComment, value.LineComment)
+	k := e.flow(key)
+	if value.Kind == KindScalar || len(value.Content) == 0 {
+		head = joinComments(head, value.HeadComment)
+		v := *value
+		v.LineComment = line
+		doc := p.Concat([]p.Doc{k, p.Text(": "), e.scalar(&v, e.opts.Indent)})
+		return e.comments(head, doc, foot)
+	}
+	block := e.block(value)
+	if value.HeadComment != "" {
+		block = p.Concat([]p.Doc{comment(value.HeadComment), p.LineBreak(), block})
+	}
+	block = p.Concat([]p.Doc{
+		p.Text(":"),
+		lineComment(line),
+		p.Nest(e.opts.Indent, p.Concat([]p.Doc{p.LineBreak(), block})),
+	})
+	flow := p.Concat([]p.Doc{p.Text(": "), e.flow(value)})
+	doc := p.Concat([]p.Doc{k, e.choose(value, block, flow)})
+	return e.comments(head, doc, foot)
+}
+
+// flow lays out the node in flow style, ignoring its comments.
+func (e *emitter) flow(n *Node) p.Doc {
+	if doc, ok := e.flows[n]; ok {
+		return doc
+	}
+	var doc p.Doc
+	switch n.Kind {
+	case KindSequence:
+		ds := make([]p.Doc, len(n.Content))
+		for i, item := range n.Content {
+			ds[i] = e.flow(item)
+		}
+		doc = p.Concat([]p.Doc{p.Text("["), p.Intercalate(p.Text(", "), ds), p.Text("]")})
+	case KindMapping:
+		ds := []p.Doc{}
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			ds = append(ds, p.Concat([]p.Doc{
+				e.flow(n.Content[i]),
+				p.Text(": "),
+				e.flow(n.Content[i+1]),
+			}))
+		}
+		doc = p.Concat([]p.Doc{p.Text("{"), p.Intercalate(p.Text(", "), ds), p.Text("}")})
+	default:
+		doc = p.Text(quote(n))
+	}
+	e.flows[n] = doc
+	return doc
+}
+
+// comments surrounds the doc with the head and the foot comments.
+func (e *emitter) comments(head string, doc p.Doc, foot string) p.Doc {
+	ds := []p.Doc{}
+	if head != "" {
+		ds = append(ds, comment(head), p.LineBreak())
+	}
+	ds = append(ds, doc)
+	if foot != "" {
+		ds = append(ds, p.LineBreak(), comment(foot))
+	}
+	return p.Concat(ds)
+}
+
+// comment lays out the lines of the comment.
+func comment(c string) p.Doc {
+	lines := strings.Split(c, "\n")
+	ds := make([]p.Doc, len(lines))
+	for i, line := range lines {
+		if !strings.HasPrefix(line, "#") {
+			line = "# " + line
+		}
+		ds[i] = p.Text(line)
+	}
+	return p.Intercalate(p.LineBreak(), ds)
+}
+
+func lineComment(c string) p.Doc {
+	if c == "" {
+		return p.Empty()
+	}
+	return p.Concat([]p.Doc{p.Text(" "), comment(strings.Replace(c, "\n", " ", -1))})
+}
+
+func joinComments(a string, b string) string {
+	if a == "" || b == "" {
+		return a + b
+	}
+	return a + "\n" + b
+}
+
+// isMultiline reports whether the node is a string written as a literal
+// block scalar.
+func isMultiline(n *Node) bool {
+	if n.Kind != KindScalar || n.Tag != TagString && n.Tag != "" || !strings.Contains(n.Value, "\n") {
+		return false
+	}
+	if strings.HasPrefix(strings.TrimLeft(n.Value, "\n"), " ") {
+		// the indentation of the block scalar would be detected wrongly.
+		return false
+	}
+	for _, line := range strings.Split(n.Value, "\n") {
+		// trailing spaces and control characters are only kept in
+		// double-quoted strings.
+		if strings.HasSuffix(line, " ") || strings.IndexFunc(line, isControl) >= 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// literal returns the indicator and the lines of the literal block scalar
+// of the string.
+func literal(s string) (string, []string) {
+	switch {
+	case !strings.HasSuffix(s, "\n"):
+		return "|-", strings.Split(s, "\n")
+	case strings.HasSuffix(s, "\n\n"):
+		return "|+", strings.Split(s[:len(s)-1], "\n")
+	default:
+		return "|", strings.Split(s[:len(s)-1], "\n")
+	}
+}
+
+// quote writes the scalar as a plain scalar if possible, or as
+// a double-quoted scalar. Multi-line strings are written in flow style.
+func quote(n *Node) string {
+	s := n.Value
+	switch n.Tag {
+	case TagString:
+		if isPlain(s) && !isTyped(s) {
+			return s
+		}
+	case "":
+		if isPlain(s) {
+			return s
+		}
+	case TagNull:
+		if s == "" {
+			return "null"
+		}
+		return s
+	default:
+		return s
+	}
+	var b strings.Builder
+	b.WriteByte('"')
+	for _, r := range s {
+		switch r {
+		case '"':
+			b.WriteString(`\"`)
+		case '\\':
+			b.WriteString(`\\`)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\t':
+			b.WriteString(`\t`)
+		case '\r':
+			b.WriteString(`\r`)
+		default:
+			if isControl(r) && r <= 0xff {
+				fmt.Fprintf(&b, `\x%02x`, r)
+			} else if isControl(r) {
+				fmt.Fprintf(&b, `\u%04X`, r)
+			} else {
+				b.WriteRune(r)
+			}
+		}
+	}
+	b.WriteByte('"')
+	return b.String()
+}
+
+// isPlain reports whether the string can be written as a plain scalar
+// both in block and flow contexts.
+func isPlain(s string) bool {
+	if s == "" || s != strings.TrimSpace(s) || strings.IndexFunc(s, isControl) >= 0 {
+		return false
+	}
+	switch s[0] {
+	case '-', '?', ':':
+		if len(s) == 1 || s[1] == ' ' || strings.HasPrefix(s, "---") {
+			return false
+		}
+	case ',', '[', ']', '{', '}', '#', '&', '*', '!', '|', '>', '\'', '"', '%', '@', '`':
+		return false
+	}
+	return !strings.HasPrefix(s, "...") &&
+		!strings.HasSuffix(s, ":") &&
+		!strings.Contains(s, ": ") &&
+		!strings.Contains(s, " #") &&
+		!strings.ContainsAny(s, ",[]{}")
+}
+
+// typed is the plain scalars read as other types than strings,
+// by YAML 1.1 or 1.2.
+var typed = map[string]bool{
+	"~": true, "null": true, "true": true, "false": true,
+	"y": true, "n": true, "yes": true, "no": true, "on": true, "off": true,
+	".inf": true, "+.inf": true, "-.inf": true, ".nan": true,
+}
+
+// timestamp matches the timestamps of YAML 1.1, and sexagesimal matches
+// the base 60 numbers of YAML 1.1 such as 1:30.
+var (
+	timestamp   = regexp.MustCompile(`^[0-9]{4}-[0-9]{1,2}-[0-9]{1,2}(([Tt]|[ \t]+)[0-9]{1,2}:[0-9]{2}:[0-9]{2}(\.[0-9]*)?([ \t]*(Z|[-+][0-9]{1,2}(:[0-9]{2})?))?)?$`)
+	sexagesimal = regexp.MustCompile(`^[-+]?[0-9][0-9_]*(:[0-5]?[0-9])+(\.[0-9_]*)?$`)
+)
+
+// isTyped reports whether the plain scalar is read as another type than
+// a string, such as a boolean, a number or a timestamp.
+func isTyped(s string) bool {
+	if typed[strings.ToLower(s)] || timestamp.MatchString(s) || sexagesimal.MatchString(s) {
+		return true
+	}
+	num := strings.Replace(s, "_", "", -1)
+	if _, err := strconv.ParseFloat(num, 64); err == nil {
+		return true
+	}
+	_, err := strconv.ParseInt(num, 0, 64)
+	return err == nil
+}
+
+// isControl reports whether the rune must be escaped, which is a C0 or C1
+// control character, DEL or a byte order mark.
+func isControl(r rune) bool {
+	return unicode.IsControl(r) || r == '\ufeff'
+}
@@ -0,0 +1,187 @@
+package yaml
+
+import (
+	"fmt"
+	"testing"
+)
+
+func scalar(value string) *Node {
+	return &Node{Kind: KindScalar, Tag: TagString, Value: value}
+}
+
+func pod() *Node {
+	return NodeOf(map[string]interface{}{
+		"kind": "Pod",
+		"metadata": map[string]interface{}{
+			"labels": map[string]interface{}{"app": "web", "tier": "frontend"},
+			"name":   "web",
+		},
+		"spec": map[string]interface{}{
+			"containers": []interface{}{
+				map[string]interface{}{"image": "nginx:1.19", "ports": []int{80, 443}},
+			},
+		},
+	})
+}
+
+func TestFormat(t *testing.T) {
+	tests := []struct {
+		width    int
+		expected string
+	}{
+		{200, `{kind: Pod, metadata: {labels: {app: web, tier: frontend}, name: web}, spec: {containers: [{image: nginx:1.19, ports: [80, 443]}]}}
+`},
+		{60, `kind: Pod
+metadata: {labels: {app: web, tier: frontend}, name: web}
+spec: {containers: [{image: nginx:1.19, ports: [80, 443]}]}
+`},
+		{30, `kind: Pod
+metadata:
+  labels:
+    app: web
+    tier: frontend
+  name: web
+spec:
+  containers:
+    - image: nginx:1.19
+      ports: [80, 443]
+`},
+	}
+	for _, tt := range tests {
+		if actual := Format(pod(), tt.width, Options{}); actual != tt.expected {
+			t.Errorf("expected: %v, actual: %v", tt.expected, actual)
+		}
+	}
+}
+
+func TestFormatIndent(t *testing.T) {
+	expected := `metadata:
+    labels:
+        app: web
+        tier: frontend
+    name: web
+`
+	n := pod().Content[3]
+	doc := &Node{Kind: KindMapping, Content: []*Node{scalar("metadata"), n}}
+	if actual := Format(doc, 20, Options{Indent: 4}); actual != expected {
+		t.Errorf("expected: %v, actual: %v", expected, actual)
+	}
+}
+
+func TestFormatScalars(t *testing.T) {
+	tests := []struct {
+		node     *Node
+		expected string
+	}{
+		{scalar("web"), "web"},
+		{scalar("nginx:1.19"), "nginx:1.19"},
+		{scalar(""), `""`},
+		{scalar("true"), `"true"`},
+		{scalar("No"), `"No"`},
+		{scalar("~"), `"~"`},
+		{scalar("8080"), `"8080"`},
+		{scalar("1_000"), `"1_000"`},
+		{scalar("0x1F"), `"0x1F"`},
+		{scalar("1.5e3"), `"1.5e3"`},
+		{scalar("a: b"), `"a: b"`},
+		{scalar("a #b"), `"a #b"`},
+		{scalar("a, b"), `"a, b"`},
+		{scalar("- a"), `"- a"`},
+		{scalar("-a"), "-a"},
+		{scalar("*ref"), `"*ref"`},
+		{scalar(" padded "), `" padded "`},
+		{scalar("tab\there"), `"tab\there"`},
+		{scalar(`say "hi"`), `say "hi"`},
+		{scalar(`"quoted"`), `"\"quoted\""`},
+		{scalar("bell\a"), `"bell\x07"`},
+		{scalar("\ufeffbom"), `"\uFEFFbom"`},
+		{scalar("next\u0085line"), `"next\x85line"`},
+		{scalar("\u009f"), `"\x9f"`},
+		{scalar("2001-12-14"), `"2001-12-14"`},
+		{scalar("2001-12-14t21:59:43.10-05:00"), `"2001-12-14t21:59:43.10-05:00"`},
+		{scalar("2001-12-14 21:59:43.10 Z"), `"2001-12-14 21:59:43.10 Z"`},
+		{scalar("2001-12"), "2001-12"},
+		{scalar("190:20:30"), `"190:20:30"`},
+		{scalar("10:70"), "10:70"},
+		{scalar("line\n"), "|\n  line"},
+		{scalar("line1\nline2"), "|-\n  line1\n  line2"},
+		{scalar("line\n\n"), "|+\n  line\n"},
+		{scalar("a\n\nb\n"), "|\n  a\n\n  b"},
+		{scalar("  indented\n"), `"  indented\n"`},
+		{scalar("trailing \n"), `"trailing \n"`},
+		{&Node{Kind: KindScalar, Tag: TagInt, Value: "8080"}, "8080"},
+		{&Node{Kind: KindScalar, Tag: TagNull}, "null"},
+		{&Node{Kind: KindScalar, Value: "true"}, "true"},
+		{&Node{Kind: KindScalar, Value: "a: b"}, `"a: b"`},
+		{&Node{Kind: KindSequence}, "[]"},
+		{&Node{Kind: KindMapping}, "{}"},
+	}
+	for _, tt := range tests {
+		if actual := Format(tt.node, 80, Options{}); actual != tt.expected+"\n" {
+			t.Errorf("expected: %v, actual: %v", tt.expected, actual)
+		}
+	}
+}
+
+func TestFormatMultiline(t *testing.T) {
+	expected := `script: |
+  echo hello
+  echo world
+args:
+  - short
+  - |-
+    multi
+    line
+`
+	n := &Node{Kind: KindMapping, Content: []*Node{
+		scalar("script"), scalar("echo hello\necho world\n"),
+		scalar("args"), {Kind: KindSequence, Content: []*Node{scalar("short"), scalar("multi\nline")}},
+	}}
+	if actual := Format(n, 80, Options{}); actual != expected {
+		t.Errorf("expected: %v, actual: %v", expected, actual)
+	}
+}
+
+func TestFormatComments(t *testing.T) {
+	expected := `# the pod
+kind: Pod # kind
+metadata:
+  # labels
+  labels: {app: web, tier: frontend}
+  name: web
+spec: # the spec
+  containers:
+    # the first one
+    - image: nginx:1.19
+      ports: [80, 443]
+    # no more
+# end
+`
+	n := pod()
+	n.Content[0].HeadComment = "the pod"
+	n.Content[1].LineComment = "# kind"
+	n.Content[3].Content[0].HeadComment = "labels"
+	n.Content[5].LineComment = "the spec"
+	containers := n.Content[5].Content[1]
+	containers.Content[0].HeadComment = "the first one"
+	containers.Content[0].FootComment = "no more"
+	n.FootComment = "end"
+	if actual := Format(n, 80, Options{}); actual != expected {
+		t.Errorf("expected: %v, actual: %v", expected, actual)
+	}
+}
+
+func ExampleFormat() {
+	n := NodeOf(map[string]interface{}{
+		"name":  "web",
+		"ports": []int{80, 443},
+		"env":   map[string]string{"MODE": "production", "DEBUG": "false"},
+	})
+	fmt.Print(Format(n, 30, Options{}))
+	// Output:
+	// env:
+	//   DEBUG: "false"
+	//   MODE: production
+	// name: web
+	// ports: [80, 443]
+}